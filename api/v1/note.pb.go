@@ -88,6 +88,56 @@ func (NoteLevel) EnumDescriptor() ([]byte, []int) {
 	return file_api_v1_note_proto_rawDescGZIP(), []int{0}
 }
 
+// PatchType is the type of the patch of a Fix
+type PatchType int32
+
+const (
+	PatchType_UNSPECIFIED_PATCH     PatchType = 0
+	PatchType_JSON_MERGE_PATCH      PatchType = 1
+	PatchType_STRATEGIC_MERGE_PATCH PatchType = 2
+)
+
+// Enum value maps for PatchType.
+var (
+	PatchType_name = map[int32]string{
+		0: "UNSPECIFIED_PATCH",
+		1: "JSON_MERGE_PATCH",
+		2: "STRATEGIC_MERGE_PATCH",
+	}
+	PatchType_value = map[string]int32{
+		"UNSPECIFIED_PATCH":     0,
+		"JSON_MERGE_PATCH":      1,
+		"STRATEGIC_MERGE_PATCH": 2,
+	}
+)
+
+func (x PatchType) Enum() *PatchType {
+	p := new(PatchType)
+	*p = x
+	return p
+}
+
+func (x PatchType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PatchType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_v1_note_proto_enumTypes[1].Descriptor()
+}
+
+func (PatchType) Type() protoreflect.EnumType {
+	return &file_api_v1_note_proto_enumTypes[1]
+}
+
+func (x PatchType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PatchType.Descriptor instead.
+func (PatchType) EnumDescriptor() ([]byte, []int) {
+	return file_api_v1_note_proto_rawDescGZIP(), []int{1}
+}
+
 // Vetters use Info to provide their information
 type Info struct {
 	state         protoimpl.MessageState
@@ -146,15 +196,187 @@ func (x *Info) GetVersion() string {
 	return ""
 }
 
+// ObjectReference identifies a Kubernetes resource a note refers to
+type ObjectReference struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// API group of the resource, empty for the core group
+	Group string `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	// API version of the resource
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// Kind of the resource, e.g. "Pod" or "VirtualService"
+	Kind string `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Namespace of the resource, empty for cluster scoped resources
+	Namespace string `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Name of the resource
+	Name string `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	// UID of the resource, empty if not known to the vetter
+	Uid string `protobuf:"bytes,6,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (x *ObjectReference) Reset() {
+	*x = ObjectReference{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_note_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ObjectReference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectReference) ProtoMessage() {}
+
+func (x *ObjectReference) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_note_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectReference.ProtoReflect.Descriptor instead.
+func (*ObjectReference) Descriptor() ([]byte, []int) {
+	return file_api_v1_note_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ObjectReference) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *ObjectReference) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ObjectReference) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ObjectReference) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ObjectReference) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ObjectReference) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+// Fix is a patch resolving a note when applied to a Kubernetes resource
+type Fix struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Short description of the change made by the patch
+	Description string `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	// Resource the patch applies to
+	Target *ObjectReference `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	// Type of the patch
+	Type PatchType `protobuf:"varint,3,opt,name=type,proto3,enum=istio.vet.v1.PatchType" json:"type,omitempty"`
+	// Patch in JSON
+	Patch string `protobuf:"bytes,4,opt,name=patch,proto3" json:"patch,omitempty"`
+}
+
+func (x *Fix) Reset() {
+	*x = Fix{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_note_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Fix) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fix) ProtoMessage() {}
+
+func (x *Fix) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_note_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fix.ProtoReflect.Descriptor instead.
+func (*Fix) Descriptor() ([]byte, []int) {
+	return file_api_v1_note_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Fix) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Fix) GetTarget() *ObjectReference {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *Fix) GetType() PatchType {
+	if x != nil {
+		return x.Type
+	}
+	return PatchType_UNSPECIFIED_PATCH
+}
+
+func (x *Fix) GetPatch() string {
+	if x != nil {
+		return x.Patch
+	}
+	return ""
+}
+
 // Vetters generate Notes after inspecting the mesh configuration
 type Note struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// MD5 checksum of the generated note
+	// Identity of the note
 	//
-	// Used as UUID for notes
+	// MD5 checksum of the vetter id, the note type and the resources the note
+	// is about. It doesn't change with the wording of the note or the version
+	// of the vetter, so it can be used to deduplicate notes across runs.
 	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	// Type of the note
 	//
@@ -176,12 +398,29 @@ type Note struct {
 	Level NoteLevel `protobuf:"varint,5,opt,name=level,proto3,enum=istio.vet.v1.NoteLevel" json:"level,omitempty"`
 	// Map of template variables which can be used by Summary and Msg
 	Attr map[string]string `protobuf:"bytes,6,rep,name=attr,proto3" json:"attr,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Resources the note refers to
+	Refs []*ObjectReference `protobuf:"bytes,7,rep,name=refs,proto3" json:"refs,omitempty"`
+	// Stable code of the note type
+	//
+	// Example "IV0401"
+	Code string `protobuf:"bytes,8,opt,name=code,proto3" json:"code,omitempty"`
+	// Short description of how to resolve the note
+	Remediation string `protobuf:"bytes,9,opt,name=remediation,proto3" json:"remediation,omitempty"`
+	// URL of the documentation of the note type
+	DocsUrl string `protobuf:"bytes,10,opt,name=docs_url,json=docsUrl,proto3" json:"docs_url,omitempty"`
+	// Patch resolving the note, if it can be fixed mechanically
+	Fix *Fix `protobuf:"bytes,11,opt,name=fix,proto3" json:"fix,omitempty"`
+	// MD5 checksum of the content of the note
+	//
+	// Changes whenever the type, level, templates, attributes, resources or fix
+	// of the note change, while its id stays the same.
+	Fingerprint string `protobuf:"bytes,12,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
 }
 
 func (x *Note) Reset() {
 	*x = Note{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1_note_proto_msgTypes[1]
+		mi := &file_api_v1_note_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -194,7 +433,7 @@ func (x *Note) String() string {
 func (*Note) ProtoMessage() {}
 
 func (x *Note) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1_note_proto_msgTypes[1]
+	mi := &file_api_v1_note_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -207,7 +446,7 @@ func (x *Note) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Note.ProtoReflect.Descriptor instead.
 func (*Note) Descriptor() ([]byte, []int) {
-	return file_api_v1_note_proto_rawDescGZIP(), []int{1}
+	return file_api_v1_note_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Note) GetId() string {
@@ -252,6 +491,48 @@ func (x *Note) GetAttr() map[string]string {
 	return nil
 }
 
+func (x *Note) GetRefs() []*ObjectReference {
+	if x != nil {
+		return x.Refs
+	}
+	return nil
+}
+
+func (x *Note) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Note) GetRemediation() string {
+	if x != nil {
+		return x.Remediation
+	}
+	return ""
+}
+
+func (x *Note) GetDocsUrl() string {
+	if x != nil {
+		return x.DocsUrl
+	}
+	return ""
+}
+
+func (x *Note) GetFix() *Fix {
+	if x != nil {
+		return x.Fix
+	}
+	return nil
+}
+
+func (x *Note) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
 var File_api_v1_note_proto protoreflect.FileDescriptor
 
 var file_api_v1_note_proto_rawDesc = []byte{
@@ -260,7 +541,27 @@ var file_api_v1_note_proto_rawDesc = []byte{
 	0x31, 0x22, 0x30, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
 	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
-	0x69, 0x6f, 0x6e, 0x22, 0xf0, 0x01, 0x0a, 0x04, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x6f, 0x6e, 0x22, 0x99, 0x01, 0x0a, 0x0f, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6e,
+	0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x75, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22,
+	0xa1, 0x01, 0x0a, 0x03, 0x46, 0x69, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72,
+	0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x06, 0x74, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x73, 0x74, 0x69,
+	0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52,
+	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x12, 0x2b, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17,
+	0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61,
+	0x74, 0x63, 0x68, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x70, 0x61, 0x74, 0x63, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x61,
+	0x74, 0x63, 0x68, 0x22, 0xbb, 0x03, 0x0a, 0x04, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02,
 	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04,
 	0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
 	0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
@@ -271,15 +572,33 @@ var file_api_v1_note_proto_rawDesc = []byte{
 	0x65, 0x76, 0x65, 0x6c, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x30, 0x0a, 0x04, 0x61,
 	0x74, 0x74, 0x72, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x73, 0x74, 0x69,
 	0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x2e, 0x41, 0x74,
-	0x74, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x61, 0x74, 0x74, 0x72, 0x1a, 0x37, 0x0a,
-	0x09, 0x41, 0x74, 0x74, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
-	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0x39, 0x0a, 0x09, 0x4e, 0x6f, 0x74, 0x65, 0x4c, 0x65,
-	0x76, 0x65, 0x6c, 0x12, 0x0a, 0x0a, 0x06, 0x55, 0x4e, 0x55, 0x53, 0x45, 0x44, 0x10, 0x00, 0x12,
-	0x08, 0x0a, 0x04, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x57, 0x41, 0x52,
-	0x4e, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10,
-	0x03, 0x42, 0x27, 0x5a, 0x25, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x74, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x61, 0x74, 0x74, 0x72, 0x12, 0x31, 0x0a,
+	0x04, 0x72, 0x65, 0x66, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x73,
+	0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x04, 0x72, 0x65, 0x66, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x6d, 0x65, 0x64,
+	0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x64, 0x6f, 0x63, 0x73, 0x5f, 0x75,
+	0x72, 0x6c, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x6f, 0x63, 0x73, 0x55, 0x72,
+	0x6c, 0x12, 0x23, 0x0a, 0x03, 0x66, 0x69, 0x78, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69,
+	0x78, 0x52, 0x03, 0x66, 0x69, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72,
+	0x70, 0x72, 0x69, 0x6e, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x66, 0x69, 0x6e,
+	0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x1a, 0x37, 0x0a, 0x09, 0x41, 0x74, 0x74, 0x72,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x2a, 0x39, 0x0a, 0x09, 0x4e, 0x6f, 0x74, 0x65, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x0a,
+	0x0a, 0x06, 0x55, 0x4e, 0x55, 0x53, 0x45, 0x44, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x4e,
+	0x46, 0x4f, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x57, 0x41, 0x52, 0x4e, 0x49, 0x4e, 0x47, 0x10,
+	0x02, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x03, 0x2a, 0x53, 0x0a, 0x09,
+	0x50, 0x61, 0x74, 0x63, 0x68, 0x54, 0x79, 0x70, 0x65, 0x12, 0x15, 0x0a, 0x11, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x5f, 0x50, 0x41, 0x54, 0x43, 0x48, 0x10, 0x00,
+	0x12, 0x14, 0x0a, 0x10, 0x4a, 0x53, 0x4f, 0x4e, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f, 0x50,
+	0x41, 0x54, 0x43, 0x48, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x54, 0x52, 0x41, 0x54, 0x45,
+	0x47, 0x49, 0x43, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f, 0x50, 0x41, 0x54, 0x43, 0x48, 0x10,
+	0x02, 0x42, 0x27, 0x5a, 0x25, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
 	0x61, 0x73, 0x70, 0x65, 0x6e, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2d,
 	0x76, 0x65, 0x74, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x33,
@@ -297,22 +616,29 @@ func file_api_v1_note_proto_rawDescGZIP() []byte {
 	return file_api_v1_note_proto_rawDescData
 }
 
-var file_api_v1_note_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_api_v1_note_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_api_v1_note_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_v1_note_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_api_v1_note_proto_goTypes = []interface{}{
-	(NoteLevel)(0), // 0: istio.vet.v1.NoteLevel
-	(*Info)(nil),   // 1: istio.vet.v1.Info
-	(*Note)(nil),   // 2: istio.vet.v1.Note
-	nil,            // 3: istio.vet.v1.Note.AttrEntry
+	(NoteLevel)(0),          // 0: istio.vet.v1.NoteLevel
+	(PatchType)(0),          // 1: istio.vet.v1.PatchType
+	(*Info)(nil),            // 2: istio.vet.v1.Info
+	(*ObjectReference)(nil), // 3: istio.vet.v1.ObjectReference
+	(*Fix)(nil),             // 4: istio.vet.v1.Fix
+	(*Note)(nil),            // 5: istio.vet.v1.Note
+	nil,                     // 6: istio.vet.v1.Note.AttrEntry
 }
 var file_api_v1_note_proto_depIdxs = []int32{
-	0, // 0: istio.vet.v1.Note.level:type_name -> istio.vet.v1.NoteLevel
-	3, // 1: istio.vet.v1.Note.attr:type_name -> istio.vet.v1.Note.AttrEntry
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	3, // 0: istio.vet.v1.Fix.target:type_name -> istio.vet.v1.ObjectReference
+	1, // 1: istio.vet.v1.Fix.type:type_name -> istio.vet.v1.PatchType
+	0, // 2: istio.vet.v1.Note.level:type_name -> istio.vet.v1.NoteLevel
+	6, // 3: istio.vet.v1.Note.attr:type_name -> istio.vet.v1.Note.AttrEntry
+	3, // 4: istio.vet.v1.Note.refs:type_name -> istio.vet.v1.ObjectReference
+	4, // 5: istio.vet.v1.Note.fix:type_name -> istio.vet.v1.Fix
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_api_v1_note_proto_init() }
@@ -334,6 +660,30 @@ func file_api_v1_note_proto_init() {
 			}
 		}
 		file_api_v1_note_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ObjectReference); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_note_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Fix); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_note_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Note); i {
 			case 0:
 				return &v.state
@@ -351,8 +701,8 @@ func file_api_v1_note_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_v1_note_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   3,
+			NumEnums:      2,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,661 @@
+//
+//Copyright 2017 Aspen Mesh Authors.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.17.3
+// source: api/v1/service.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListVettersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListVettersRequest) Reset() {
+	*x = ListVettersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVettersRequest) ProtoMessage() {}
+
+func (x *ListVettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVettersRequest.ProtoReflect.Descriptor instead.
+func (*ListVettersRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{0}
+}
+
+type ListVettersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Vetters []*Info `protobuf:"bytes,1,rep,name=vetters,proto3" json:"vetters,omitempty"`
+}
+
+func (x *ListVettersResponse) Reset() {
+	*x = ListVettersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVettersResponse) ProtoMessage() {}
+
+func (x *ListVettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVettersResponse.ProtoReflect.Descriptor instead.
+func (*ListVettersResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListVettersResponse) GetVetters() []*Info {
+	if x != nil {
+		return x.Vetters
+	}
+	return nil
+}
+
+type RunVetRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Ids of the vetters to run, all vetters are run if empty
+	VetterIds []string `protobuf:"bytes,1,rep,name=vetter_ids,json=vetterIds,proto3" json:"vetter_ids,omitempty"`
+	// Only return notes about resources in this namespace if set
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+}
+
+func (x *RunVetRequest) Reset() {
+	*x = RunVetRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunVetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunVetRequest) ProtoMessage() {}
+
+func (x *RunVetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunVetRequest.ProtoReflect.Descriptor instead.
+func (*RunVetRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RunVetRequest) GetVetterIds() []string {
+	if x != nil {
+		return x.VetterIds
+	}
+	return nil
+}
+
+func (x *RunVetRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// VetterResult holds the outcome of running a single vetter
+type VetterResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Info  *Info   `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Notes []*Note `protobuf:"bytes,2,rep,name=notes,proto3" json:"notes,omitempty"`
+	// Error reported by the vetter, empty if the vetter ran successfully
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *VetterResult) Reset() {
+	*x = VetterResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VetterResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VetterResult) ProtoMessage() {}
+
+func (x *VetterResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VetterResult.ProtoReflect.Descriptor instead.
+func (*VetterResult) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VetterResult) GetInfo() *Info {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *VetterResult) GetNotes() []*Note {
+	if x != nil {
+		return x.Notes
+	}
+	return nil
+}
+
+func (x *VetterResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type RunVetResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*VetterResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *RunVetResponse) Reset() {
+	*x = RunVetResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunVetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunVetResponse) ProtoMessage() {}
+
+func (x *RunVetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunVetResponse.ProtoReflect.Descriptor instead.
+func (*RunVetResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RunVetResponse) GetResults() []*VetterResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type WatchNotesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Ids of the vetters to watch, all vetters are watched if empty
+	VetterIds []string `protobuf:"bytes,1,rep,name=vetter_ids,json=vetterIds,proto3" json:"vetter_ids,omitempty"`
+	// Only stream notes about resources in this namespace if set
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+}
+
+func (x *WatchNotesRequest) Reset() {
+	*x = WatchNotesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchNotesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchNotesRequest) ProtoMessage() {}
+
+func (x *WatchNotesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchNotesRequest.ProtoReflect.Descriptor instead.
+func (*WatchNotesRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchNotesRequest) GetVetterIds() []string {
+	if x != nil {
+		return x.VetterIds
+	}
+	return nil
+}
+
+func (x *WatchNotesRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// WatchNotesResponse holds the changes to the notes of a single vetter
+type WatchNotesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Info *Info `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	// Notes which were not generated by the previous run of the vetter
+	NewNotes []*Note `protobuf:"bytes,2,rep,name=new_notes,json=newNotes,proto3" json:"new_notes,omitempty"`
+	// Notes which were generated by the previous run but not the latest one
+	ResolvedNotes []*Note `protobuf:"bytes,3,rep,name=resolved_notes,json=resolvedNotes,proto3" json:"resolved_notes,omitempty"`
+	// Error reported by the latest run of the vetter, if any
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Notes generated by both runs whose fingerprint changed, e.g. because of
+	// a different attribute value
+	ChangedNotes []*Note `protobuf:"bytes,5,rep,name=changed_notes,json=changedNotes,proto3" json:"changed_notes,omitempty"`
+}
+
+func (x *WatchNotesResponse) Reset() {
+	*x = WatchNotesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_service_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchNotesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchNotesResponse) ProtoMessage() {}
+
+func (x *WatchNotesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_service_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchNotesResponse.ProtoReflect.Descriptor instead.
+func (*WatchNotesResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchNotesResponse) GetInfo() *Info {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *WatchNotesResponse) GetNewNotes() []*Note {
+	if x != nil {
+		return x.NewNotes
+	}
+	return nil
+}
+
+func (x *WatchNotesResponse) GetResolvedNotes() []*Note {
+	if x != nil {
+		return x.ResolvedNotes
+	}
+	return nil
+}
+
+func (x *WatchNotesResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WatchNotesResponse) GetChangedNotes() []*Note {
+	if x != nil {
+		return x.ChangedNotes
+	}
+	return nil
+}
+
+var File_api_v1_service_proto protoreflect.FileDescriptor
+
+var file_api_v1_service_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65,
+	0x74, 0x2e, 0x76, 0x31, 0x1a, 0x11, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x6e, 0x6f, 0x74,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x56,
+	0x65, 0x74, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x43, 0x0a,
+	0x13, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x65, 0x74, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x76, 0x65, 0x74, 0x74, 0x65, 0x72, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65,
+	0x74, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07, 0x76, 0x65, 0x74, 0x74, 0x65,
+	0x72, 0x73, 0x22, 0x4c, 0x0a, 0x0d, 0x52, 0x75, 0x6e, 0x56, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x65, 0x74, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x76, 0x65, 0x74, 0x74, 0x65, 0x72, 0x49,
+	0x64, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65,
+	0x22, 0x76, 0x0a, 0x0c, 0x56, 0x65, 0x74, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x26, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x12, 0x28, 0x0a, 0x05, 0x6e, 0x6f, 0x74, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e,
+	0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x05, 0x6e, 0x6f, 0x74,
+	0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x46, 0x0a, 0x0e, 0x52, 0x75, 0x6e, 0x56,
+	0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x07, 0x72, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x73,
+	0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x74, 0x74, 0x65,
+	0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x22, 0x50, 0x0a, 0x11, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x65, 0x74, 0x74, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x76, 0x65, 0x74, 0x74, 0x65,
+	0x72, 0x49, 0x64, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x22, 0xf7, 0x01, 0x0a, 0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x04, 0x69, 0x6e, 0x66,
+	0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e,
+	0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x69, 0x6e, 0x66,
+	0x6f, 0x12, 0x2f, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x5f, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x08, 0x6e, 0x65, 0x77, 0x4e, 0x6f, 0x74,
+	0x65, 0x73, 0x12, 0x39, 0x0a, 0x0e, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x64, 0x5f, 0x6e,
+	0x6f, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x73, 0x74,
+	0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x0d,
+	0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x64, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x37, 0x0a, 0x0d, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x5f, 0x6e,
+	0x6f, 0x74, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x73, 0x74,
+	0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x0c,
+	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x32, 0xf8, 0x01, 0x0a,
+	0x0a, 0x56, 0x65, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x52, 0x0a, 0x0b, 0x4c,
+	0x69, 0x73, 0x74, 0x56, 0x65, 0x74, 0x74, 0x65, 0x72, 0x73, 0x12, 0x20, 0x2e, 0x69, 0x73, 0x74,
+	0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x65,
+	0x74, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x69,
+	0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x56, 0x65, 0x74, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x43, 0x0a, 0x06, 0x52, 0x75, 0x6e, 0x56, 0x65, 0x74, 0x12, 0x1b, 0x2e, 0x69, 0x73, 0x74, 0x69,
+	0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6e, 0x56, 0x65, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76,
+	0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6e, 0x56, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x51, 0x0a, 0x0a, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x74,
+	0x65, 0x73, 0x12, 0x1f, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x76, 0x65, 0x74, 0x2e,
+	0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x42, 0x27, 0x5a, 0x25, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x73, 0x70, 0x65, 0x6e, 0x6d, 0x65, 0x73, 0x68, 0x2f,
+	0x69, 0x73, 0x74, 0x69, 0x6f, 0x2d, 0x76, 0x65, 0x74, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_v1_service_proto_rawDescOnce sync.Once
+	file_api_v1_service_proto_rawDescData = file_api_v1_service_proto_rawDesc
+)
+
+func file_api_v1_service_proto_rawDescGZIP() []byte {
+	file_api_v1_service_proto_rawDescOnce.Do(func() {
+		file_api_v1_service_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_v1_service_proto_rawDescData)
+	})
+	return file_api_v1_service_proto_rawDescData
+}
+
+var file_api_v1_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_v1_service_proto_goTypes = []interface{}{
+	(*ListVettersRequest)(nil),  // 0: istio.vet.v1.ListVettersRequest
+	(*ListVettersResponse)(nil), // 1: istio.vet.v1.ListVettersResponse
+	(*RunVetRequest)(nil),       // 2: istio.vet.v1.RunVetRequest
+	(*VetterResult)(nil),        // 3: istio.vet.v1.VetterResult
+	(*RunVetResponse)(nil),      // 4: istio.vet.v1.RunVetResponse
+	(*WatchNotesRequest)(nil),   // 5: istio.vet.v1.WatchNotesRequest
+	(*WatchNotesResponse)(nil),  // 6: istio.vet.v1.WatchNotesResponse
+	(*Info)(nil),                // 7: istio.vet.v1.Info
+	(*Note)(nil),                // 8: istio.vet.v1.Note
+}
+var file_api_v1_service_proto_depIdxs = []int32{
+	7,  // 0: istio.vet.v1.ListVettersResponse.vetters:type_name -> istio.vet.v1.Info
+	7,  // 1: istio.vet.v1.VetterResult.info:type_name -> istio.vet.v1.Info
+	8,  // 2: istio.vet.v1.VetterResult.notes:type_name -> istio.vet.v1.Note
+	3,  // 3: istio.vet.v1.RunVetResponse.results:type_name -> istio.vet.v1.VetterResult
+	7,  // 4: istio.vet.v1.WatchNotesResponse.info:type_name -> istio.vet.v1.Info
+	8,  // 5: istio.vet.v1.WatchNotesResponse.new_notes:type_name -> istio.vet.v1.Note
+	8,  // 6: istio.vet.v1.WatchNotesResponse.resolved_notes:type_name -> istio.vet.v1.Note
+	8,  // 7: istio.vet.v1.WatchNotesResponse.changed_notes:type_name -> istio.vet.v1.Note
+	0,  // 8: istio.vet.v1.VetService.ListVetters:input_type -> istio.vet.v1.ListVettersRequest
+	2,  // 9: istio.vet.v1.VetService.RunVet:input_type -> istio.vet.v1.RunVetRequest
+	5,  // 10: istio.vet.v1.VetService.WatchNotes:input_type -> istio.vet.v1.WatchNotesRequest
+	1,  // 11: istio.vet.v1.VetService.ListVetters:output_type -> istio.vet.v1.ListVettersResponse
+	4,  // 12: istio.vet.v1.VetService.RunVet:output_type -> istio.vet.v1.RunVetResponse
+	6,  // 13: istio.vet.v1.VetService.WatchNotes:output_type -> istio.vet.v1.WatchNotesResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_api_v1_service_proto_init() }
+func file_api_v1_service_proto_init() {
+	if File_api_v1_service_proto != nil {
+		return
+	}
+	file_api_v1_note_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_api_v1_service_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListVettersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListVettersResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RunVetRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VetterResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RunVetResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchNotesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchNotesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_api_v1_service_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_v1_service_proto_goTypes,
+		DependencyIndexes: file_api_v1_service_proto_depIdxs,
+		MessageInfos:      file_api_v1_service_proto_msgTypes,
+	}.Build()
+	File_api_v1_service_proto = out.File
+	file_api_v1_service_proto_rawDesc = nil
+	file_api_v1_service_proto_goTypes = nil
+	file_api_v1_service_proto_depIdxs = nil
+}
@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// VetServiceClient is the client API for VetService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VetServiceClient interface {
+	// ListVetters returns information about all vetters
+	ListVetters(ctx context.Context, in *ListVettersRequest, opts ...grpc.CallOption) (*ListVettersResponse, error)
+	// RunVet runs the vetters and returns the generated notes
+	RunVet(ctx context.Context, in *RunVetRequest, opts ...grpc.CallOption) (*RunVetResponse, error)
+	// WatchNotes streams the notes of continuously running vetters
+	//
+	// The current notes of every vetter are sent first as new notes, followed
+	// by the notes which appear or are resolved as resources change.
+	WatchNotes(ctx context.Context, in *WatchNotesRequest, opts ...grpc.CallOption) (VetService_WatchNotesClient, error)
+}
+
+type vetServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVetServiceClient(cc grpc.ClientConnInterface) VetServiceClient {
+	return &vetServiceClient{cc}
+}
+
+func (c *vetServiceClient) ListVetters(ctx context.Context, in *ListVettersRequest, opts ...grpc.CallOption) (*ListVettersResponse, error) {
+	out := new(ListVettersResponse)
+	err := c.cc.Invoke(ctx, "/istio.vet.v1.VetService/ListVetters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vetServiceClient) RunVet(ctx context.Context, in *RunVetRequest, opts ...grpc.CallOption) (*RunVetResponse, error) {
+	out := new(RunVetResponse)
+	err := c.cc.Invoke(ctx, "/istio.vet.v1.VetService/RunVet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vetServiceClient) WatchNotes(ctx context.Context, in *WatchNotesRequest, opts ...grpc.CallOption) (VetService_WatchNotesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &VetService_ServiceDesc.Streams[0], "/istio.vet.v1.VetService/WatchNotes", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vetServiceWatchNotesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type VetService_WatchNotesClient interface {
+	Recv() (*WatchNotesResponse, error)
+	grpc.ClientStream
+}
+
+type vetServiceWatchNotesClient struct {
+	grpc.ClientStream
+}
+
+func (x *vetServiceWatchNotesClient) Recv() (*WatchNotesResponse, error) {
+	m := new(WatchNotesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VetServiceServer is the server API for VetService service.
+// All implementations must embed UnimplementedVetServiceServer
+// for forward compatibility
+type VetServiceServer interface {
+	// ListVetters returns information about all vetters
+	ListVetters(context.Context, *ListVettersRequest) (*ListVettersResponse, error)
+	// RunVet runs the vetters and returns the generated notes
+	RunVet(context.Context, *RunVetRequest) (*RunVetResponse, error)
+	// WatchNotes streams the notes of continuously running vetters
+	//
+	// The current notes of every vetter are sent first as new notes, followed
+	// by the notes which appear or are resolved as resources change.
+	WatchNotes(*WatchNotesRequest, VetService_WatchNotesServer) error
+	mustEmbedUnimplementedVetServiceServer()
+}
+
+// UnimplementedVetServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedVetServiceServer struct {
+}
+
+func (UnimplementedVetServiceServer) ListVetters(context.Context, *ListVettersRequest) (*ListVettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVetters not implemented")
+}
+func (UnimplementedVetServiceServer) RunVet(context.Context, *RunVetRequest) (*RunVetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunVet not implemented")
+}
+func (UnimplementedVetServiceServer) WatchNotes(*WatchNotesRequest, VetService_WatchNotesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchNotes not implemented")
+}
+func (UnimplementedVetServiceServer) mustEmbedUnimplementedVetServiceServer() {}
+
+// UnsafeVetServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VetServiceServer will
+// result in compilation errors.
+type UnsafeVetServiceServer interface {
+	mustEmbedUnimplementedVetServiceServer()
+}
+
+func RegisterVetServiceServer(s grpc.ServiceRegistrar, srv VetServiceServer) {
+	s.RegisterService(&VetService_ServiceDesc, srv)
+}
+
+func _VetService_ListVetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VetServiceServer).ListVetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/istio.vet.v1.VetService/ListVetters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VetServiceServer).ListVetters(ctx, req.(*ListVettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VetService_RunVet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunVetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VetServiceServer).RunVet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/istio.vet.v1.VetService/RunVet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VetServiceServer).RunVet(ctx, req.(*RunVetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VetService_WatchNotes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchNotesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VetServiceServer).WatchNotes(m, &vetServiceWatchNotesServer{stream})
+}
+
+type VetService_WatchNotesServer interface {
+	Send(*WatchNotesResponse) error
+	grpc.ServerStream
+}
+
+type vetServiceWatchNotesServer struct {
+	grpc.ServerStream
+}
+
+func (x *vetServiceWatchNotesServer) Send(m *WatchNotesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// VetService_ServiceDesc is the grpc.ServiceDesc for VetService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VetService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "istio.vet.v1.VetService",
+	HandlerType: (*VetServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListVetters",
+			Handler:    _VetService_ListVetters_Handler,
+		},
+		{
+			MethodName: "RunVet",
+			Handler:    _VetService_RunVet_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchNotes",
+			Handler:       _VetService_WatchNotes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/v1/service.proto",
+}
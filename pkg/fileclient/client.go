@@ -0,0 +1,234 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fileclient provides Kubernetes and Istio clients backed by objects
+// loaded from manifest files instead of a live cluster.
+//
+// The clients are fakes from client-go, so informers built on top of them
+// behave exactly like informers watching a cluster which contains only the
+// loaded objects. This allows vetters to run unchanged against rendered
+// manifests, e.g. the output of helm template or kustomize build.
+package fileclient
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+	istioversioned "istio.io/client-go/pkg/clientset/versioned"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	istioscheme "istio.io/client-go/pkg/clientset/versioned/scheme"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8sscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Stdin is the path which reads manifests from standard input.
+const Stdin = "-"
+
+const defaultNamespace = "default"
+
+// clusterScopedKinds are the kinds which are not assigned the default
+// namespace when the manifest does not specify one.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"StorageClass":                   true,
+	"PriorityClass":                  true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+}
+
+var manifestExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// Clients holds the fake clients populated from manifests.
+type Clients struct {
+	K8s   kubernetes.Interface
+	Istio istioversioned.Interface
+}
+
+type loader struct {
+	decoder runtime.Decoder
+	k8s     []runtime.Object
+	istio   []runtime.Object
+	// loaded maps the group, kind, namespace and name of the loaded objects
+	// to where they are loaded from, to replace objects loaded twice.
+	loaded map[string]loadedObject
+}
+
+type loadedObject struct {
+	source string
+	index  int
+}
+
+func newLoader() *loader {
+	s := runtime.NewScheme()
+	// Both schemes are generated, registration can't fail.
+	_ = k8sscheme.AddToScheme(s)
+	_ = istioscheme.AddToScheme(s)
+	return &loader{
+		decoder: serializer.NewCodecFactory(s).UniversalDeserializer(),
+		loaded:  map[string]loadedObject{},
+	}
+}
+
+// New loads the Kubernetes and Istio objects from the manifests at paths
+// and returns clients which serve them. A path may be a file, a directory
+// which is walked recursively for .yaml, .yml and .json files, or Stdin.
+// Documents of a kind unknown to the Kubernetes and Istio clients are
+// skipped. An object loaded more than once, e.g. from overlapping
+// directories, is replaced by the last one loaded. Stdin is read once, even
+// if given more than once.
+func New(paths []string, stdin io.Reader) (*Clients, error) {
+	l := newLoader()
+	readStdin := false
+	for _, p := range paths {
+		if p == Stdin {
+			if readStdin {
+				continue
+			}
+			readStdin = true
+			if err := l.load(stdin, "stdin"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := l.loadPath(p); err != nil {
+			return nil, err
+		}
+	}
+	return &Clients{
+		K8s:   k8sfake.NewSimpleClientset(l.k8s...),
+		Istio: istiofake.NewSimpleClientset(l.istio...),
+	}, nil
+}
+
+func (l *loader) loadPath(root string) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		// Files named explicitly are loaded whatever their extension.
+		if p != root && !manifestExtensions[strings.ToLower(filepath.Ext(p))] {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return l.load(f, p)
+	})
+}
+
+// load reads a stream of YAML or JSON documents separated by "---".
+func (l *loader) load(r io.Reader, source string) error {
+	yr := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	for i := 0; ; i++ {
+		doc, err := yr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", source, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		if err := l.decode(doc, fmt.Sprintf("%s[%d]", source, i)); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *loader) decode(doc []byte, source string) error {
+	js, err := utilyaml.ToJSON(doc)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %s", source, err)
+	}
+	// Skip documents which only contain comments.
+	if bytes.Equal(bytes.TrimSpace(js), []byte("null")) {
+		return nil
+	}
+	obj, gvk, err := l.decoder.Decode(js, nil, nil)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			glog.V(2).Infof("Skipping %s: %s", source, err)
+			return nil
+		}
+		return fmt.Errorf("failed to decode %s: %s", source, err)
+	}
+	if list, ok := obj.(*corev1.List); ok {
+		for i, item := range list.Items {
+			if err := l.decode(item.Raw, fmt.Sprintf("%s.items[%d]", source, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !clusterScopedKinds[gvk.Kind] {
+		if m, err := meta.Accessor(obj); err == nil && m.GetNamespace() == "" {
+			m.SetNamespace(defaultNamespace)
+		}
+	}
+	l.add(obj, *gvk, source)
+	return nil
+}
+
+// add adds obj of kind gvk loaded from source to the objects served by the
+// clients, replacing the same object loaded before.
+func (l *loader) add(obj runtime.Object, gvk schema.GroupVersionKind, source string) {
+	objects := &l.k8s
+	if istioscheme.Scheme.Recognizes(gvk) {
+		objects = &l.istio
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		*objects = append(*objects, obj)
+		return
+	}
+	key := strings.Join([]string{gvk.Group, gvk.Kind, m.GetNamespace(), m.GetName()}, "/")
+	if prev, ok := l.loaded[key]; ok {
+		glog.Warningf("%s %s/%s in %s replaces the one in %s", gvk.Kind, m.GetNamespace(), m.GetName(),
+			source, prev.source)
+		(*objects)[prev.index] = obj
+		l.loaded[key] = loadedObject{source: source, index: prev.index}
+		return
+	}
+	l.loaded[key] = loadedObject{source: source, index: len(*objects)}
+	*objects = append(*objects, obj)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fileclient
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const manifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+  labels:
+    istio-injection: enabled
+---
+# Only a comment
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-1
+  namespace: shop
+spec:
+  containers:
+  - name: web
+    image: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - name: http
+    port: 80
+---
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: web
+  namespace: shop
+spec:
+  hosts:
+  - web
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: unknown
+`
+
+const list = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "istio", "namespace": "istio-system"}}
+  ]
+}`
+
+var _ = Describe("Loading manifests", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fileclient")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "nested"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "nested", "list.json"), []byte(list), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not: [yaml"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("serves Kubernetes and Istio objects from stdin and directories", func() {
+		c, err := New([]string{Stdin, dir}, strings.NewReader(manifests))
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := context.Background()
+		ns, err := c.K8s.CoreV1().Namespaces().Get(ctx, "shop", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Namespace).To(BeEmpty())
+		Expect(ns.Labels).To(HaveKeyWithValue("istio-injection", "enabled"))
+
+		_, err = c.K8s.CoreV1().Pods("shop").Get(ctx, "web-1", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("defaulting the namespace of namespaced objects")
+		_, err = c.K8s.CoreV1().Services("default").Get(ctx, "web", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("expanding lists")
+		_, err = c.K8s.CoreV1().ConfigMaps("istio-system").Get(ctx, "istio", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		vs, err := c.Istio.NetworkingV1beta1().VirtualServices("shop").Get(ctx, "web", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vs.Spec.GetHosts()).To(ConsistOf("web"))
+	})
+
+	It("replaces objects loaded twice and reads stdin once", func() {
+		path := filepath.Join(dir, "shop.yaml")
+		Expect(ioutil.WriteFile(path, []byte(manifests), 0644)).To(Succeed())
+		relabelled := strings.Replace(manifests, "istio-injection: enabled", "istio-injection: disabled", 1)
+		c, err := New([]string{path, dir, Stdin, Stdin}, strings.NewReader(relabelled))
+		Expect(err).NotTo(HaveOccurred())
+
+		ns, err := c.K8s.CoreV1().Namespaces().Get(context.Background(), "shop", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Labels).To(HaveKeyWithValue("istio-injection", "disabled"))
+		vss, err := c.Istio.NetworkingV1beta1().VirtualServices("shop").List(context.Background(), metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vss.Items).To(HaveLen(1))
+	})
+
+	It("returns an error on malformed manifests", func() {
+		_, err := New([]string{Stdin}, strings.NewReader("kind: [Pod"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error on missing files", func() {
+		_, err := New([]string{filepath.Join(dir, "missing.yaml")}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,13 @@
+package fileclient
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFileclient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fileclient Suite")
+}
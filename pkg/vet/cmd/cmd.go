@@ -21,16 +21,28 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aspenmesh/istio-vet/pkg/meshclient"
 	"github.com/aspenmesh/istio-vet/pkg/util/logs"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-var cfgFile string
+var (
+	cfgFile       string
+	outputFormat  string
+	fromFiles     []string
+	failOn        string
+	publishEvents bool
+	reportName    string
+	baselineFile  string
+	writeBaseline string
+	showResolved  bool
+)
 
 const (
 	// DefaultConfigFile is the default config file for vet tool
@@ -49,13 +61,19 @@ and applications deployed in the mesh.
 For more details, see 'https://github.com/aspenmesh/istio-vet'
 `,
 	RunE: vet,
+	// Errors are printed by Execute
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		if e, ok := err.(*exitError); ok {
+			fmt.Fprintln(os.Stderr, e)
+			os.Exit(e.code)
+		}
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(-1)
 	}
 }
@@ -70,6 +88,41 @@ func init() {
 	// Copy those flags into root command
 	meshclient.BindKubeConfigToFlags(RootCmd.PersistentFlags())
 	RootCmd.PersistentFlags().AddFlagSet(pflag.CommandLine)
+
+	RootCmd.Flags().StringVarP(&outputFormat, "output", "o", report.FormatText,
+		"Output format, one of: "+strings.Join(report.Formats, "|"))
+	RootCmd.Flags().StringSliceVar(&fromFiles, "from-files", nil,
+		"Vet the resources in these manifest files or directories instead of a cluster, \"-\" reads from stdin")
+	RootCmd.Flags().StringVar(&failOn, "fail-on", "",
+		"Exit with a non-zero code if notes at or above this level are generated, one of: info|warning|error")
+	RootCmd.Flags().StringVar(&baselineFile, "baseline", "",
+		"Only report the notes not in this baseline file, written by --write-baseline")
+	RootCmd.Flags().StringVar(&writeBaseline, "write-baseline", "",
+		"Write all generated notes to this baseline file")
+	RootCmd.Flags().BoolVar(&showResolved, "show-resolved", false,
+		"With --baseline, also report the notes of the baseline which are not generated anymore")
+	RootCmd.PersistentFlags().BoolVar(&publishEvents, "events", false,
+		"Publish notes as Kubernetes Events on the objects they refer to")
+	RootCmd.PersistentFlags().StringVar(&reportName, "report-name", "",
+		"Write the notes to the VetReport and NamespaceVetReport resources with this name")
+	RootCmd.PersistentFlags().StringSlice("enable", nil,
+		"Vetters or note types to enable, e.g. vetters disabled by default")
+	RootCmd.PersistentFlags().StringSlice("disable", nil,
+		"Vetters or note types to disable, e.g. podsinmesh or init-image-mismatch")
+	RootCmd.PersistentFlags().String("namespace", "",
+		"Only vet this namespace, listing resources with namespace scoped permissions")
+	RootCmd.PersistentFlags().String("namespace-selector", "",
+		"Only vet the namespaces matching this label selector, e.g. team=web")
+	RootCmd.PersistentFlags().String("istio-namespace", "",
+		"Namespace of the Istio control plane, discovered from the istiod deployment if empty")
+	RootCmd.PersistentFlags().String("cluster-domain", "",
+		"DNS domain of the cluster, discovered from the MeshConfig trustDomain if empty")
+	RootCmd.PersistentFlags().Int("workers", 0,
+		"Number of vetters run concurrently, the number of CPUs if 0")
+	RootCmd.PersistentFlags().Duration("vetter-timeout", time.Minute,
+		"Fail vetters running for longer than this, unlimited if 0")
+	RootCmd.PersistentFlags().Duration("sync-timeout", 2*time.Minute,
+		"Fail if the resources can't be listed from the cluster within this time, unlimited if 0")
 }
 
 // WordSepNormalizeFunc changes all flags that contain "_" separators
@@ -102,6 +155,6 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
@@ -0,0 +1,67 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// Exit codes of the vet command. Any other failure, e.g. invalid flags,
+// exits with -1.
+const (
+	// ExitNotes is returned when notes at or above the --fail-on level
+	// were generated.
+	ExitNotes = 2
+	// ExitVetterError is returned when at least one vetter failed to run.
+	ExitVetterError = 3
+	// ExitClusterError is returned when the cluster can't be reached or the
+	// resource caches fail to sync.
+	ExitClusterError = 4
+)
+
+// exitError is returned by commands which need to exit with a specific code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// failOnLevels maps the values accepted by --fail-on to note levels.
+var failOnLevels = map[string]apiv1.NoteLevel{
+	"info":    apiv1.NoteLevel_INFO,
+	"warning": apiv1.NoteLevel_WARNING,
+	"error":   apiv1.NoteLevel_ERROR,
+}
+
+// parseFailOn returns the note level for a --fail-on value. An empty value
+// returns NoteLevel_UNUSED, meaning notes never fail the command.
+func parseFailOn(s string) (apiv1.NoteLevel, error) {
+	if s == "" {
+		return apiv1.NoteLevel_UNUSED, nil
+	}
+	l, ok := failOnLevels[strings.ToLower(s)]
+	if !ok {
+		return apiv1.NoteLevel_UNUSED, fmt.Errorf("invalid --fail-on level %q, must be one of: info, warning, error", s)
+	}
+	return l, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [type|code]",
+	Short: "Explains the notes generated by vetters",
+	Long: `Explains the notes generated by vetters.
+
+Explain prints the long form explanation of a note type, given its type like
+"missing-service-port-prefix" or its code like "IV0401", along with examples
+of offending and fixed configuration and the vetter generating it. Without
+arguments, all note types are listed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: explain,
+}
+
+func init() {
+	RootCmd.AddCommand(explainCmd)
+}
+
+// findNoteType returns the note type with the given type or code, or nil.
+func findNoteType(types []*vetter.NoteType, typeOrCode string) *vetter.NoteType {
+	for _, t := range types {
+		if t.Type == typeOrCode || strings.EqualFold(t.Code, typeOrCode) {
+			return t
+		}
+	}
+	return nil
+}
+
+func explain(cmd *cobra.Command, args []string) error {
+	types := vetter.NoteTypes()
+	out := cmd.OutOrStdout()
+	if len(args) == 0 {
+		tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "CODE\tTYPE\tVETTER")
+		for _, t := range types {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Code, t.Type, t.Vetter)
+		}
+		return tw.Flush()
+	}
+
+	t := findNoteType(types, args[0])
+	if t == nil {
+		return fmt.Errorf("unknown note type or code %q, run \"vet explain\" to list them", args[0])
+	}
+	fmt.Fprintf(out, "Code:          %s\n", t.Code)
+	fmt.Fprintf(out, "Type:          %s\n", t.Type)
+	fmt.Fprintf(out, "Vetter:        %s\n", t.Vetter)
+	fmt.Fprintf(out, "Remediation:   %s\n", t.Remediation)
+	fmt.Fprintf(out, "Documentation: %s\n\n", t.DocsURL)
+	fmt.Fprint(out, t.Doc)
+	return nil
+}
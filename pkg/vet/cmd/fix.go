@@ -0,0 +1,135 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/aspenmesh/istio-vet/pkg/meshclient"
+	"github.com/aspenmesh/istio-vet/pkg/vet/fix"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+var (
+	fixDryRun bool
+	fixApply  bool
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Prints or applies the fixes of notes",
+	Long: `Prints or applies the fixes of notes.
+
+Some notes can be fixed mechanically, e.g. by adding a missing label or
+renaming service ports. Fix runs the vetters and prints these fixes as
+"kubectl patch" commands. Nothing is changed in the cluster unless --apply
+is given.`,
+	RunE: runFix,
+}
+
+func init() {
+	RootCmd.AddCommand(fixCmd)
+
+	fixCmd.Flags().BoolVar(&fixDryRun, "dry-run", true,
+		"Print the fixes without applying them")
+	fixCmd.Flags().BoolVar(&fixApply, "apply", false,
+		"Apply the fixes to the cluster")
+	fixCmd.Flags().StringSliceVar(&fromFiles, "from-files", nil,
+		"Vet the resources in these manifest files or directories instead of a cluster, \"-\" reads from stdin")
+}
+
+// newApplier returns an Applier patching the resources of the cluster.
+func newApplier(c meshclient.Interface) (*fix.Applier, error) {
+	dynamicClient, err := dynamic.NewForConfig(c.Config())
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(c.Config())
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	return fix.NewApplier(dynamicClient, mapper), nil
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	if fixApply && cmd.Flags().Changed("dry-run") && fixDryRun {
+		return fmt.Errorf("--dry-run and --apply are mutually exclusive")
+	}
+	if fixApply && len(fromFiles) > 0 {
+		return fmt.Errorf("--apply can't be used with --from-files")
+	}
+	selector, err := newSelector()
+	if err != nil {
+		return err
+	}
+	scope, err := newScope()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	k8sClient, istioClient, err := newClients()
+	if err != nil {
+		return err
+	}
+	informerFactory := newInformerFactory(k8sClient, istioClient, scope, newEnvironment(k8sClient))
+	vList, err := newVetters(selector, informerFactory)
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	err = syncInformers(stopCh, informerFactory)
+	close(stopCh)
+	if err != nil {
+		return &exitError{code: ExitClusterError, err: err}
+	}
+
+	r := report.RunContext(context.Background(), vList, runOptions())
+	fixes := fix.Fixes(r)
+	out := cmd.OutOrStdout()
+	if !fixApply {
+		fix.Write(out, fixes)
+		return nil
+	}
+
+	applier, err := newApplier(k8sClient.(meshclient.Interface))
+	if err != nil {
+		return &exitError{code: ExitClusterError, err: err}
+	}
+	failed := 0
+	for _, f := range fixes {
+		if err := applier.Apply(context.Background(), f); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Failed: %s: %s\n", f.GetDescription(), err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "Applied: %s\n", f.GetDescription())
+	}
+	if failed > 0 {
+		return &exitError{code: ExitClusterError, err: fmt.Errorf("%d fix(es) failed to apply", failed)}
+	}
+	return nil
+}
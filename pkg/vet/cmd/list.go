@@ -0,0 +1,71 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the vetters",
+	Long: `Lists the vetters.
+
+List shows the id, version and description of every vetter, whether it is
+enabled by --enable and --disable, and the note types disabled.`,
+	Args: cobra.NoArgs,
+	RunE: list,
+}
+
+func init() {
+	RootCmd.AddCommand(listCmd)
+}
+
+func list(cmd *cobra.Command, args []string) error {
+	selector, err := newSelector()
+	if err != nil {
+		return err
+	}
+	// Vetters are only created for their Info, the factory is never started
+	// so it doesn't need clients.
+	f := newInformerFactory(nil, nil, util.Scope{}, util.Environment{})
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tVERSION\tENABLED\tDISABLED NOTE TYPES\tDESCRIPTION")
+	for _, r := range vetter.Registrations() {
+		var disabled []string
+		for _, t := range r.NoteTypes {
+			if !selector.NoteTypeEnabled(t.Type) {
+				disabled = append(disabled, t.Type)
+			}
+		}
+		disabledTypes := "-"
+		if len(disabled) > 0 {
+			disabledTypes = strings.Join(disabled, ",")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n", r.ID, r.New(f).Info().GetVersion(),
+			selector.Enabled(r.ID), disabledTypes, r.Description)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/grpcserver"
+	"github.com/aspenmesh/istio-vet/pkg/vet/server"
+	"github.com/aspenmesh/istio-vet/pkg/vet/watch"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+var (
+	listenAddress string
+	grpcAddress   string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves vet results over HTTP",
+	Long: `Serves vet results over HTTP.
+
+Serve continuously vets the mesh like the watch command and exposes the
+latest notes over a REST API:
+
+  /v1/notes    notes of all vetters, filtered by the optional query
+               parameters namespace, level, type and vetter
+  /v1/vetters  id and version of every vetter
+  /healthz     liveness probe
+  /readyz      readiness probe, succeeds once all vetters ran
+  /metrics     Prometheus metrics
+
+With --grpc-address the VetService gRPC API defined in api/v1/service.proto
+is served as well.`,
+	RunE: serve,
+}
+
+func init() {
+	RootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&listenAddress, "listen-address", ":8080",
+		"Address the HTTP server listens on")
+	serveCmd.Flags().StringVar(&grpcAddress, "grpc-address", "",
+		"Address the gRPC server listens on, disabled if empty")
+	serveCmd.Flags().DurationVar(&watchDebounce, "debounce", 5*time.Second,
+		"Wait for changes to settle for this long before re-running vetters")
+}
+
+func serve(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	m, err := newWatchedMesh()
+	if err != nil {
+		return err
+	}
+
+	srvErr := make(chan error, 2)
+	mux := http.NewServeMux()
+	mux.Handle("/", server.NewHandler(m.watcher))
+	mux.Handle("/metrics", m.metrics.Handler())
+	srv := &http.Server{Addr: listenAddress, Handler: mux}
+	go func() {
+		glog.Infof("Serving HTTP on %s", listenAddress)
+		srvErr <- srv.ListenAndServe()
+	}()
+
+	var grpcLis net.Listener
+	if grpcAddress != "" {
+		if grpcLis, err = net.Listen("tcp", grpcAddress); err != nil {
+			return err
+		}
+	}
+
+	stopCh := stopOnSignal()
+	if err := syncInformers(stopCh, m.informerFactory); err != nil {
+		return &exitError{code: ExitClusterError, err: err}
+	}
+
+	// RunVet lists resources from the caches, only serve once they synced.
+	var grpcSrv *grpc.Server
+	if grpcLis != nil {
+		grpcSrv = grpc.NewServer()
+		apiv1.RegisterVetServiceServer(grpcSrv, grpcserver.New(m.vetters, m.watcher, m.runOptions))
+		go func() {
+			glog.Infof("Serving gRPC on %s", grpcAddress)
+			srvErr <- grpcSrv.Serve(grpcLis)
+		}()
+	}
+	go m.watcher.Run(stopCh, func(u *watch.Update) {
+		m.onUpdate(u)
+		glog.V(2).Infof("Re-ran %d vetter(s) with changed notes", len(u.Vetters))
+	})
+
+	select {
+	case err := <-srvErr:
+		return err
+	case <-stopCh:
+	}
+	if grpcSrv != nil {
+		grpcSrv.Stop()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
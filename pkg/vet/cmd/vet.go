@@ -17,49 +17,38 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"os"
+	"time"
 
-	"github.com/golang/glog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	istioversioned "istio.io/client-go/pkg/clientset/versioned"
 	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/fileclient"
 	"github.com/aspenmesh/istio-vet/pkg/istioclient"
 	"github.com/aspenmesh/istio-vet/pkg/meshclient"
+	"github.com/aspenmesh/istio-vet/pkg/vet/events"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vet/vetreport"
 	"github.com/aspenmesh/istio-vet/pkg/vetter"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/applabel"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/conflictingvirtualservicehost"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/danglingroutedestinationhost"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/meshversion"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/podsinmesh"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/serviceassociation"
-	"github.com/aspenmesh/istio-vet/pkg/vetter/serviceportprefix"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+	// Registers the vetters
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/all"
 )
 
-func printNote(level, summary, msg string) {
-	if len(summary) > 0 {
-		fmt.Printf("%s\n", summary)
-		if len(msg) > 0 {
-			b := make([]byte, len(summary))
-			for i := range b {
-				b[i] = '='
-			}
-			fmt.Printf("%s\n", b)
-		} else {
-			fmt.Println()
-		}
-	}
-	if len(msg) > 0 {
-		fmt.Printf("%s: %s\n\n", level, msg)
-	}
-}
-
 type metaInformerFactory struct {
 	k8s   informers.SharedInformerFactory
 	istio istioinformer.SharedInformerFactory
+	env   util.Environment
 }
 
 func (m *metaInformerFactory) K8s() informers.SharedInformerFactory {
@@ -68,75 +57,244 @@ func (m *metaInformerFactory) K8s() informers.SharedInformerFactory {
 func (m *metaInformerFactory) Istio() istioinformer.SharedInformerFactory {
 	return m.istio
 }
+func (m *metaInformerFactory) Environment() util.Environment {
+	return m.env
+}
 
-func vet(cmd *cobra.Command, args []string) error {
+// newClients returns the clients vetters list resources from, either
+// connected to the cluster or serving the manifests given by --from-files.
+func newClients() (kubernetes.Interface, istioversioned.Interface, error) {
+	if len(fromFiles) > 0 {
+		c, err := fileclient.New(fromFiles, os.Stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c.K8s, c.Istio, nil
+	}
 	k8sClient, err := meshclient.New()
 	if err != nil {
-		return err
+		return nil, nil, &exitError{code: ExitClusterError, err: err}
 	}
 	istioClient, err := istioclient.New(k8sClient.Config())
 	if err != nil {
-		return err
+		return nil, nil, &exitError{code: ExitClusterError, err: err}
 	}
+	return k8sClient, istioClient, nil
+}
+
+// newInformerFactory returns the informer factory listing the resources in
+// scope from the clients, for vetters running in env.
+func newInformerFactory(k8sClient kubernetes.Interface, istioClient istioversioned.Interface,
+	scope util.Scope, env util.Environment) *metaInformerFactory {
+	m := &metaInformerFactory{env: env}
+	m.k8s, m.istio = scope.InformerFactories(k8sClient, istioClient, env)
+	return m
+}
+
+// newScope returns the namespaces to vet given by the namespace and
+// namespace-selector flags or config file keys.
+func newScope() (util.Scope, error) {
+	return util.ParseScope(viper.GetString("namespace"), viper.GetString("namespace-selector"))
+}
 
-	kubeInformerFactory := informers.NewSharedInformerFactory(k8sClient, 0)
-	istioInformerFactory := istioinformer.NewSharedInformerFactory(istioClient, 0)
-	informerFactory := &metaInformerFactory{
-		k8s:   kubeInformerFactory,
-		istio: istioInformerFactory,
+// newEnvironment returns where Istio is installed, given by the
+// istio-namespace and cluster-domain flags or config file keys, or else
+// discovered from the cluster.
+func newEnvironment(k8sClient kubernetes.Interface) util.Environment {
+	env := util.Environment{
+		IstioNamespace: viper.GetString("istio-namespace"),
+		ClusterDomain:  viper.GetString("cluster-domain"),
 	}
+	ctx := context.Background()
+	if timeout := viper.GetDuration("sync-timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return util.DiscoverEnvironment(ctx, k8sClient, env)
+}
 
-	vList := []vetter.Vetter{
-		vetter.Vetter(podsinmesh.NewVetter(informerFactory)),
-		vetter.Vetter(meshversion.NewVetter(informerFactory)),
-		vetter.Vetter(applabel.NewVetter(informerFactory)),
-		vetter.Vetter(serviceportprefix.NewVetter(informerFactory)),
-		vetter.Vetter(serviceassociation.NewVetter(informerFactory)),
-		vetter.Vetter(danglingroutedestinationhost.NewVetter(informerFactory)),
-		vetter.Vetter(conflictingvirtualservicehost.NewVetter(informerFactory)),
+// newSelector returns the selection of vetters and note types given by the
+// enable and disable flags or config file keys.
+func newSelector() (*vetter.Selector, error) {
+	return vetter.NewSelector(viper.GetStringSlice("enable"), viper.GetStringSlice("disable"))
+}
+
+// newVetters returns the vetters selected by selector, configured from the
+// config file, which drop the notes suppressed by annotations. Vetters
+// register the informers they need, so this must be called before the
+// factory is started.
+func newVetters(selector *vetter.Selector, f vetter.ResourceListGetter) ([]vetter.Vetter, error) {
+	vList, err := selector.NewVetters(f, vetterConfig())
+	if err != nil {
+		return nil, err
 	}
+	return vetter.NewSuppressor(f).Instrument(vList), nil
+}
 
-	stopCh := make(chan struct{})
+// vetterConfig returns the "vetters" section of the config file, holding the
+// config of vetters keyed by their id.
+func vetterConfig() map[string]interface{} {
+	return viper.GetStringMap("vetters")
+}
+
+// runOptions returns how to run vetters as given by the workers and
+// vetter-timeout flags or config file keys.
+func runOptions() vetter.RunOptions {
+	return vetter.RunOptions{
+		Workers: viper.GetInt("workers"),
+		Timeout: viper.GetDuration("vetter-timeout"),
+	}
+}
 
-	kubeInformerFactory.Start(stopCh)
-	oks := kubeInformerFactory.WaitForCacheSync(stopCh)
-	for inf, ok := range oks {
+// withEvents wraps vList to publish their notes as events if requested.
+func withEvents(k8sClient kubernetes.Interface, vList []vetter.Vetter) ([]vetter.Vetter, error) {
+	if !publishEvents {
+		return vList, nil
+	}
+	if len(fromFiles) > 0 {
+		return nil, fmt.Errorf("--events can't be used with --from-files")
+	}
+	return events.NewPublisher(k8sClient).Instrument(vList), nil
+}
+
+// newReportWriter returns a writer for the report resources named by
+// --report-name, or nil if it isn't set.
+func newReportWriter(k8sClient kubernetes.Interface) (*vetreport.Writer, error) {
+	if reportName == "" {
+		return nil, nil
+	}
+	c, ok := k8sClient.(meshclient.Interface)
+	if !ok {
+		return nil, fmt.Errorf("--report-name can't be used with --from-files")
+	}
+	dynamicClient, err := dynamic.NewForConfig(c.Config())
+	if err != nil {
+		return nil, &exitError{code: ExitClusterError, err: err}
+	}
+	return vetreport.NewWriter(dynamicClient, reportName), nil
+}
+
+// syncInformers starts the informer factories, which run until stopCh is
+// closed, and waits for their caches to sync. It fails if they don't sync
+// within the sync-timeout flag or config file key, e.g. because the cluster
+// can't be reached, as informers retry listing resources forever.
+func syncInformers(stopCh <-chan struct{}, f *metaInformerFactory) error {
+	timeout := viper.GetDuration("sync-timeout")
+	syncCh := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(syncCh)
+		var timeoutCh <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			timeoutCh = timer.C
+		}
+		select {
+		case <-stopCh:
+		case <-timeoutCh:
+		case <-done:
+		}
+	}()
+
+	f.k8s.Start(stopCh)
+	for inf, ok := range f.k8s.WaitForCacheSync(syncCh) {
 		if !ok {
-			glog.Fatalf("Failed to sync %s", inf)
+			return fmt.Errorf("Failed to sync %s within %s", inf, timeout)
 		}
 	}
 
-	istioInformerFactory.Start(stopCh)
-	oks = istioInformerFactory.WaitForCacheSync(stopCh)
-	for inf, ok := range oks {
+	f.istio.Start(stopCh)
+	for inf, ok := range f.istio.WaitForCacheSync(syncCh) {
 		if !ok {
-			glog.Fatalf("Failed to sync %s", inf)
+			return fmt.Errorf("Failed to sync %s within %s", inf, timeout)
 		}
 	}
+	return nil
+}
+
+func vet(cmd *cobra.Command, args []string) error {
+	if err := report.CheckFormat(outputFormat); err != nil {
+		return err
+	}
+	failLevel, err := parseFailOn(failOn)
+	if err != nil {
+		return err
+	}
+	if showResolved && baselineFile == "" {
+		return fmt.Errorf("--show-resolved requires --baseline")
+	}
+	selector, err := newSelector()
+	if err != nil {
+		return err
+	}
+	scope, err := newScope()
+	if err != nil {
+		return err
+	}
+	var baseline *report.Report
+	if baselineFile != "" {
+		if baseline, err = report.ReadBaseline(baselineFile); err != nil {
+			return err
+		}
+	}
+	// Flags are valid, don't print the usage on errors past this point.
+	cmd.SilenceUsage = true
+
+	k8sClient, istioClient, err := newClients()
+	if err != nil {
+		return err
+	}
+
+	informerFactory := newInformerFactory(k8sClient, istioClient, scope, newEnvironment(k8sClient))
+	vList, err := newVetters(selector, informerFactory)
+	if err != nil {
+		return err
+	}
+	if vList, err = withEvents(k8sClient, vList); err != nil {
+		return err
+	}
+
+	reportWriter, err := newReportWriter(k8sClient)
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	err = syncInformers(stopCh, informerFactory)
 	// Just run through once
 	close(stopCh)
+	if err != nil {
+		return &exitError{code: ExitClusterError, err: err}
+	}
 
-	for _, v := range vList {
-		nList, err := v.Vet()
-		if err != nil {
-			fmt.Printf("Vetter: \"%s\" reported error: %s\n", v.Info().GetId(), err)
-			continue
+	r := report.RunContext(context.Background(), vList, runOptions())
+	if writeBaseline != "" {
+		if err := report.WriteBaseline(writeBaseline, r); err != nil {
+			return fmt.Errorf("failed to write baseline: %s", err)
 		}
-		if len(nList) > 0 {
-			for i := range nList {
-				var ts []string
-				for k, v := range nList[i].Attr {
-					ts = append(ts, "${"+k+"}", v)
-				}
-				r := strings.NewReplacer(ts...)
-				summary := r.Replace(nList[i].GetSummary())
-				msg := r.Replace(nList[i].GetMsg())
-				printNote(nList[i].GetLevel().String(), summary, msg)
-			}
-		} else {
-			fmt.Printf("Vetter \"%s\" ran successfully and generated no notes\n\n", v.Info().GetId())
+	}
+	// The cluster report always holds all notes, the output only the new
+	// ones if a baseline is given.
+	out := r
+	if baseline != nil {
+		out = r.Diff(baseline, showResolved)
+	}
+	if err := report.Write(os.Stdout, out, outputFormat); err != nil {
+		return err
+	}
+	if reportWriter != nil {
+		if err := reportWriter.Write(context.Background(), r); err != nil {
+			return &exitError{code: ExitClusterError, err: fmt.Errorf("failed to write report: %s", err)}
 		}
 	}
-
+	if n := r.Errors(); n > 0 {
+		return &exitError{code: ExitVetterError, err: fmt.Errorf("%d vetter(s) reported errors", n)}
+	}
+	if failLevel != apiv1.NoteLevel_UNUSED && out.MaxLevel() >= failLevel {
+		return &exitError{code: ExitNotes, err: fmt.Errorf("notes at or above level %s were generated", failLevel)}
+	}
 	return nil
 }
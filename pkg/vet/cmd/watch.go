@@ -0,0 +1,211 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+
+	"github.com/aspenmesh/istio-vet/pkg/vet/metrics"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vet/vetreport"
+	"github.com/aspenmesh/istio-vet/pkg/vet/watch"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+var (
+	watchDebounce  time.Duration
+	metricsAddress string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Continuously vets the mesh",
+	Long: `Continuously vets the mesh.
+
+Watch keeps the resource caches up to date and re-runs the vetters affected
+by every change. Notes which newly appeared or were resolved are reported.
+Changes are batched until none happened for the --debounce period.
+
+With --metrics-address Prometheus metrics are served on /metrics.`,
+	RunE: watchVet,
+}
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVarP(&outputFormat, "output", "o", report.FormatText,
+		"Output format, one of: "+strings.Join(report.Formats, "|"))
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 5*time.Second,
+		"Wait for changes to settle for this long before re-running vetters")
+	watchCmd.Flags().StringVar(&metricsAddress, "metrics-address", "",
+		"Address serving Prometheus metrics on /metrics, disabled if empty")
+}
+
+// newTargets returns the vetters along with the resources which trigger
+// re-running them.
+func newTargets(vList []vetter.Vetter) []watch.Target {
+	targets := make([]watch.Target, len(vList))
+	for i, v := range vList {
+		targets[i] = watch.Target{Vetter: v}
+		if r, ok := vetter.Lookup(v.Info().GetId()); ok {
+			targets[i].Resources = r.Resources
+		}
+	}
+	return targets
+}
+
+// onUpdate writes the latest results to the report resources if requested.
+func (m *watchedMesh) onUpdate(u *watch.Update) {
+	if m.reportWriter == nil {
+		return
+	}
+	if err := m.reportWriter.Write(context.Background(), m.watcher.Report()); err != nil {
+		glog.Errorf("Failed to write report: %s", err)
+	}
+}
+
+// watchedResources returns the resources listed by any of targets.
+func watchedResources(targets []watch.Target) []string {
+	seen := map[string]bool{}
+	var resources []string
+	for _, t := range targets {
+		for _, r := range t.Resources {
+			if !seen[r] {
+				seen[r] = true
+				resources = append(resources, r)
+			}
+		}
+	}
+	return resources
+}
+
+// stopOnSignal returns a channel which is closed on SIGINT or SIGTERM.
+func stopOnSignal() <-chan struct{} {
+	stopCh := make(chan struct{})
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		s := <-sigCh
+		glog.Infof("Received %s, stopping", s)
+		close(stopCh)
+	}()
+	return stopCh
+}
+
+// watchedMesh holds the continuously running vetters of the watch and serve
+// commands.
+type watchedMesh struct {
+	informerFactory *metaInformerFactory
+	vetters         []vetter.Vetter
+	watcher         *watch.Watcher
+	metrics         *metrics.Metrics
+	reportWriter    *vetreport.Writer
+
+	// runOptions are shared by the watcher and the gRPC server, to not run
+	// a vetter which timed out again before it returns.
+	runOptions vetter.RunOptions
+}
+
+// newWatchedMesh returns a watcher for the selected vetters. The informer factory
+// still needs to be started.
+func newWatchedMesh() (*watchedMesh, error) {
+	selector, err := newSelector()
+	if err != nil {
+		return nil, err
+	}
+	scope, err := newScope()
+	if err != nil {
+		return nil, err
+	}
+	k8sClient, istioClient, err := newClients()
+	if err != nil {
+		return nil, err
+	}
+	m := &watchedMesh{
+		informerFactory: newInformerFactory(k8sClient, istioClient, scope, newEnvironment(k8sClient)),
+		metrics:         metrics.New(),
+		runOptions:      runOptions(),
+	}
+	m.runOptions.Orphans = &vetter.Orphans{}
+	vList, err := newVetters(selector, m.informerFactory)
+	if err != nil {
+		return nil, err
+	}
+	m.vetters, err = withEvents(k8sClient, m.metrics.Instrument(vList))
+	if err != nil {
+		return nil, err
+	}
+	if m.reportWriter, err = newReportWriter(k8sClient); err != nil {
+		return nil, err
+	}
+	targets := newTargets(m.vetters)
+	m.watcher, err = watch.New(m.informerFactory, targets, watchDebounce, m.runOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.metrics.RegisterReport(m.watcher); err != nil {
+		return nil, err
+	}
+	if err := m.metrics.RegisterInformers(m.informerFactory, watchedResources(targets)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func watchVet(cmd *cobra.Command, args []string) error {
+	if err := report.CheckFormat(outputFormat); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	m, err := newWatchedMesh()
+	if err != nil {
+		return err
+	}
+	if metricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.metrics.Handler())
+		go func() {
+			glog.Infof("Serving metrics on %s", metricsAddress)
+			if err := http.ListenAndServe(metricsAddress, mux); err != nil {
+				glog.Errorf("Failed to serve metrics: %s", err)
+			}
+		}()
+	}
+
+	stopCh := stopOnSignal()
+	if err := syncInformers(stopCh, m.informerFactory); err != nil {
+		return &exitError{code: ExitClusterError, err: err}
+	}
+
+	m.watcher.Run(stopCh, func(u *watch.Update) {
+		m.onUpdate(u)
+		if err := watch.WriteUpdate(os.Stdout, u, outputFormat); err != nil {
+			glog.Errorf("Failed to write update: %s", err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events publishes notes as Kubernetes Events on the objects they
+// refer to, so they show up in "kubectl describe".
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+// Component is the source component of the published events.
+const Component = "istio-vet"
+
+// Publisher creates an Event on every object a note refers to. The name of
+// an event is derived from the note id, so repeated runs generating the same
+// note bump the count of the existing event.
+type Publisher struct {
+	client kubernetes.Interface
+
+	mu     sync.Mutex
+	events map[string]*corev1.Event
+}
+
+// NewPublisher returns a Publisher creating events with client.
+func NewPublisher(client kubernetes.Interface) *Publisher {
+	return &Publisher{
+		client: client,
+		events: map[string]*corev1.Event{},
+	}
+}
+
+// Instrument returns vList with every vetter wrapped to publish the notes
+// of its runs.
+func (p *Publisher) Instrument(vList []vetter.Vetter) []vetter.Vetter {
+	out := make([]vetter.Vetter, len(vList))
+	for i, v := range vList {
+		out[i] = &publishingVetter{Vetter: v, publisher: p}
+	}
+	return out
+}
+
+type publishingVetter struct {
+	vetter.Vetter
+	publisher *Publisher
+}
+
+func (v *publishingVetter) Unwrap() vetter.Vetter {
+	return v.Vetter
+}
+
+func (v *publishingVetter) Vet() ([]*apiv1.Note, error) {
+	return v.VetContext(context.Background())
+}
+
+func (v *publishingVetter) VetContext(ctx context.Context) ([]*apiv1.Note, error) {
+	notes, err := vetter.VetContext(ctx, v.Vetter)
+	if err != nil {
+		return notes, err
+	}
+	if ctx.Err() != nil {
+		// The run already failed, e.g. timed out, don't publish its notes.
+		return nil, ctx.Err()
+	}
+	v.publisher.Publish(notes)
+	return notes, nil
+}
+
+// Publish creates or updates the events for notes on the namespaced objects
+// in their Refs. Failures are logged, they don't affect the vetters.
+func (p *Publisher) Publish(notes []*apiv1.Note) {
+	for _, n := range notes {
+		for _, r := range n.GetRefs() {
+			if r.GetNamespace() == "" {
+				continue
+			}
+			if err := p.publish(objectReference(r), n); err != nil {
+				glog.Errorf("Failed to publish event for note %s: %s", n.GetId(), err)
+			}
+		}
+	}
+}
+
+func objectReference(r *apiv1.ObjectReference) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: schema.GroupVersion{Group: r.GetGroup(), Version: r.GetVersion()}.String(),
+		Kind:       r.GetKind(),
+		Namespace:  r.GetNamespace(),
+		Name:       r.GetName(),
+		UID:        types.UID(r.GetUid()),
+	}
+}
+
+// eventName returns the name of the event for note n on the object ref, like
+// "<name>.<kind>.<note id>" with the kind lower cased, so notes on objects
+// of different kinds with the same name don't share an event.
+func eventName(ref *corev1.ObjectReference, n *apiv1.Note) string {
+	return fmt.Sprintf("%s.%s.%s", ref.Name, strings.ToLower(ref.Kind), n.GetId())
+}
+
+// eventType returns the event type for a note level.
+func eventType(level apiv1.NoteLevel) string {
+	if level >= apiv1.NoteLevel_WARNING {
+		return corev1.EventTypeWarning
+	}
+	return corev1.EventTypeNormal
+}
+
+// reason turns a note type like "missing-app-label" into an event reason
+// like "MissingAppLabel".
+func reason(noteType string) string {
+	var b strings.Builder
+	for _, w := range strings.Split(noteType, "-") {
+		if w != "" {
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return b.String()
+}
+
+func (p *Publisher) publish(ref *corev1.ObjectReference, n *apiv1.Note) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx := context.Background()
+	events := p.client.CoreV1().Events(ref.Namespace)
+	name := eventName(ref, n)
+	now := metav1.NewTime(time.Now())
+	message := report.Render(n).GetSummary()
+
+	ev, ok := p.events[name]
+	if !ok {
+		ev = &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ref.Namespace,
+			},
+			InvolvedObject: *ref,
+			Reason:         reason(n.GetType()),
+			Message:        message,
+			Source:         corev1.EventSource{Component: Component},
+			FirstTimestamp: now,
+			LastTimestamp:  now,
+			Count:          1,
+			Type:           eventType(n.GetLevel()),
+		}
+		created, err := events.Create(ctx, ev, metav1.CreateOptions{})
+		if err == nil {
+			p.events[name] = created
+			return nil
+		}
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// Published by an earlier process.
+		if ev, err = events.Get(ctx, name, metav1.GetOptions{}); err != nil {
+			return err
+		}
+	}
+
+	ev = ev.DeepCopy()
+	ev.Count++
+	ev.LastTimestamp = now
+	ev.Message = message
+	if ev.InvolvedObject.UID != ref.UID {
+		// The object was re-created under the same name.
+		ev.InvolvedObject = *ref
+	}
+	updated, err := events.Update(ctx, ev, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		// Expired or modified behind our back, start over on the next run.
+		delete(p.events, name)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	p.events[name] = updated
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+type staticVetter struct {
+	notes []*apiv1.Note
+}
+
+func (v *staticVetter) Vet() ([]*apiv1.Note, error) { return v.notes, nil }
+func (v *staticVetter) Info() *apiv1.Info           { return &apiv1.Info{Id: "static"} }
+
+// blockingVetter returns its notes once release is closed.
+type blockingVetter struct {
+	staticVetter
+	release chan struct{}
+}
+
+func (v *blockingVetter) Vet() ([]*apiv1.Note, error) {
+	<-v.release
+	return v.notes, nil
+}
+
+func podRef(name string) []*apiv1.ObjectReference {
+	return []*apiv1.ObjectReference{
+		{Version: "v1", Kind: "Pod", Namespace: "default", Name: name, Uid: "uid-" + name},
+	}
+}
+
+var _ = Describe("Publisher", func() {
+	var client *k8sfake.Clientset
+
+	listEvents := func() []corev1.Event {
+		l, err := client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return l.Items
+	}
+
+	BeforeEach(func() {
+		client = k8sfake.NewSimpleClientset()
+	})
+
+	It("creates events on the objects notes refer to", func() {
+		NewPublisher(client).Publish([]*apiv1.Note{
+			{
+				Id:      "1",
+				Type:    "missing-app-label",
+				Summary: "Missing app label on ${pod_name}",
+				Level:   apiv1.NoteLevel_WARNING,
+				Attr:    map[string]string{"pod_name": "a", "namespace": "default"},
+				Refs:    podRef("a"),
+			},
+			{
+				Id:    "2",
+				Type:  "host-in-multiple-vs",
+				Level: apiv1.NoteLevel_INFO,
+				Refs: []*apiv1.ObjectReference{
+					{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService", Namespace: "default", Name: "vs1"},
+					{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService", Namespace: "default", Name: "vs2"},
+				},
+			},
+		})
+
+		evs := listEvents()
+		Expect(evs).To(HaveLen(3))
+		byName := map[string]corev1.Event{}
+		for _, ev := range evs {
+			byName[ev.Name] = ev
+		}
+		ev := byName["a.pod.1"]
+		Expect(ev.Type).To(Equal(corev1.EventTypeWarning))
+		Expect(ev.Reason).To(Equal("MissingAppLabel"))
+		Expect(ev.Message).To(Equal("Missing app label on a"))
+		Expect(ev.Count).To(BeEquivalentTo(1))
+		Expect(ev.InvolvedObject.APIVersion).To(Equal("v1"))
+		Expect(ev.InvolvedObject.Kind).To(Equal("Pod"))
+		Expect(ev.InvolvedObject.Name).To(Equal("a"))
+		Expect(string(ev.InvolvedObject.UID)).To(Equal("uid-a"))
+		Expect(ev.Source.Component).To(Equal(Component))
+
+		for _, name := range []string{"vs1.virtualservice.2", "vs2.virtualservice.2"} {
+			ev = byName[name]
+			Expect(ev.Type).To(Equal(corev1.EventTypeNormal))
+			Expect(ev.Reason).To(Equal("HostInMultipleVs"))
+			Expect(ev.InvolvedObject.APIVersion).To(Equal("networking.istio.io/v1beta1"))
+			Expect(ev.InvolvedObject.Kind).To(Equal("VirtualService"))
+		}
+	})
+
+	It("deduplicates events by note id", func() {
+		n := &apiv1.Note{
+			Id:    "1",
+			Type:  "missing-app-label",
+			Level: apiv1.NoteLevel_WARNING,
+			Refs:  podRef("a"),
+		}
+		vList := NewPublisher(client).Instrument([]vetter.Vetter{&staticVetter{notes: []*apiv1.Note{n}}})
+		for i := 0; i < 2; i++ {
+			notes, err := vList[0].Vet()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(HaveLen(1))
+		}
+		evs := listEvents()
+		Expect(evs).To(HaveLen(1))
+		Expect(evs[0].Count).To(BeEquivalentTo(2))
+
+		By("picking up events published by an earlier process")
+		NewPublisher(client).Publish([]*apiv1.Note{n})
+		evs = listEvents()
+		Expect(evs).To(HaveLen(1))
+		Expect(evs[0].Count).To(BeEquivalentTo(3))
+	})
+
+	It("publishes separate events on objects of different kinds with the same name", func() {
+		NewPublisher(client).Publish([]*apiv1.Note{{
+			Id: "1",
+			Refs: []*apiv1.ObjectReference{
+				{Version: "v1", Kind: "Pod", Namespace: "default", Name: "a"},
+				{Version: "v1", Kind: "Service", Namespace: "default", Name: "a"},
+			},
+		}})
+		var names []string
+		for _, ev := range listEvents() {
+			names = append(names, ev.Name)
+		}
+		Expect(names).To(ConsistOf("a.pod.1", "a.service.1"))
+	})
+
+	It("doesn't publish the notes of runs which timed out", func() {
+		v := &blockingVetter{
+			staticVetter: staticVetter{notes: []*apiv1.Note{{Id: "1", Refs: podRef("a")}}},
+			release:      make(chan struct{}),
+		}
+		vList := NewPublisher(client).Instrument([]vetter.Vetter{v})
+		_, err := vetter.Run(context.Background(), vList[0], 10*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		close(v.release)
+		Consistently(listEvents, 100*time.Millisecond).Should(BeEmpty())
+	})
+
+	It("skips notes not referring to a namespaced object", func() {
+		NewPublisher(client).Publish([]*apiv1.Note{
+			{Id: "1", Attr: map[string]string{"num_user_pods": "3"}},
+			{Id: "2", Refs: []*apiv1.ObjectReference{{Version: "v1", Kind: "Namespace", Name: "default"}}},
+		})
+		Expect(listEvents()).To(BeEmpty())
+	})
+})
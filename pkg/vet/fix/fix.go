@@ -0,0 +1,119 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fix prints and applies the patches vetters attach to notes which
+// can be fixed mechanically.
+package fix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+// FieldManager is the field manager of the applied patches.
+const FieldManager = "istio-vet"
+
+// Fixes returns the fixes of the notes in r. Notes on several pods of a
+// workload have the same fix, it is only returned once.
+func Fixes(r *report.Report) []*apiv1.Fix {
+	fixes := []*apiv1.Fix{}
+	seen := map[string]bool{}
+	for _, res := range r.Results {
+		for _, n := range res.Notes {
+			f := n.GetFix()
+			if f == nil {
+				continue
+			}
+			t := f.GetTarget()
+			key := strings.Join([]string{t.GetGroup(), t.GetKind(), t.GetNamespace(), t.GetName(), f.GetPatch()}, "/")
+			if !seen[key] {
+				seen[key] = true
+				fixes = append(fixes, f)
+			}
+		}
+	}
+	return fixes
+}
+
+// kubectlPatchTypes maps patch types to the --type of "kubectl patch".
+var kubectlPatchTypes = map[apiv1.PatchType]string{
+	apiv1.PatchType_JSON_MERGE_PATCH:      "merge",
+	apiv1.PatchType_STRATEGIC_MERGE_PATCH: "strategic",
+}
+
+// Write writes fixes as the equivalent "kubectl patch" commands.
+func Write(w io.Writer, fixes []*apiv1.Fix) {
+	for _, f := range fixes {
+		t := f.GetTarget()
+		resource := strings.ToLower(t.GetKind())
+		if t.GetGroup() != "" {
+			resource += "." + t.GetGroup()
+		}
+		fmt.Fprintf(w, "# %s\n", f.GetDescription())
+		fmt.Fprintf(w, "kubectl -n %s patch %s %s --type %s -p '%s'\n\n",
+			t.GetNamespace(), resource, t.GetName(), kubectlPatchTypes[f.GetType()], f.GetPatch())
+	}
+}
+
+// patchType returns the Kubernetes patch type of t.
+func patchType(t apiv1.PatchType) (types.PatchType, error) {
+	switch t {
+	case apiv1.PatchType_JSON_MERGE_PATCH:
+		return types.MergePatchType, nil
+	case apiv1.PatchType_STRATEGIC_MERGE_PATCH:
+		return types.StrategicMergePatchType, nil
+	}
+	return "", fmt.Errorf("unsupported patch type %s", t)
+}
+
+// Applier applies fixes to the resources in a cluster.
+type Applier struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+}
+
+// NewApplier returns an Applier patching resources with client, using
+// mapper to find the resources of the kinds fixes refer to.
+func NewApplier(client dynamic.Interface, mapper meta.RESTMapper) *Applier {
+	return &Applier{client: client, mapper: mapper}
+}
+
+// Apply applies f to its target.
+func (a *Applier) Apply(ctx context.Context, f *apiv1.Fix) error {
+	t := f.GetTarget()
+	pt, err := patchType(f.GetType())
+	if err != nil {
+		return err
+	}
+	mapping, err := a.mapper.RESTMapping(schema.GroupKind{Group: t.GetGroup(), Kind: t.GetKind()}, t.GetVersion())
+	if err != nil {
+		return err
+	}
+	_, err = a.client.Resource(mapping.Resource).Namespace(t.GetNamespace()).Patch(ctx,
+		t.GetName(), pt, []byte(f.GetPatch()), metav1.PatchOptions{FieldManager: FieldManager})
+	return err
+}
@@ -0,0 +1,13 @@
+package fix
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFix(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fix Suite")
+}
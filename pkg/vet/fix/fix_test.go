@@ -0,0 +1,110 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fix
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+var deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func workloadFix(name string) *apiv1.Fix {
+	return &apiv1.Fix{
+		Description: "Roll out " + name,
+		Target:      &apiv1.ObjectReference{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "default", Name: name},
+		Type:        apiv1.PatchType_STRATEGIC_MERGE_PATCH,
+		Patch:       `{"spec":{}}`,
+	}
+}
+
+var _ = Describe("Fixes", func() {
+	It("returns the fixes of notes once", func() {
+		r := &report.Report{Results: []*report.VetterResult{
+			{Notes: []*apiv1.Note{
+				{Id: "a", Fix: workloadFix("foo")},
+				{Id: "b"},
+				{Id: "c", Fix: workloadFix("foo")},
+			}},
+			{Notes: []*apiv1.Note{{Id: "d", Fix: workloadFix("bar")}}},
+		}}
+		fixes := Fixes(r)
+		Expect(fixes).To(HaveLen(2))
+		Expect(fixes[0].GetTarget().GetName()).To(Equal("foo"))
+		Expect(fixes[1].GetTarget().GetName()).To(Equal("bar"))
+	})
+
+	It("writes fixes as kubectl commands", func() {
+		var b bytes.Buffer
+		Write(&b, []*apiv1.Fix{workloadFix("foo")})
+		Expect(b.String()).To(Equal("# Roll out foo\n" +
+			"kubectl -n default patch deployment.apps foo --type strategic -p '{\"spec\":{}}'\n\n"))
+	})
+})
+
+var _ = Describe("Applier", func() {
+	var (
+		client  *dynamicfake.FakeDynamicClient
+		applier *Applier
+		patches []k8stesting.PatchAction
+	)
+
+	BeforeEach(func() {
+		client = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		patches = nil
+		client.PrependReactor("patch", "*", func(a k8stesting.Action) (bool, runtime.Object, error) {
+			patches = append(patches, a.(k8stesting.PatchAction))
+			return true, nil, nil
+		})
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+		applier = NewApplier(client, mapper)
+	})
+
+	It("patches the target of fixes", func() {
+		Expect(applier.Apply(context.Background(), workloadFix("foo"))).To(Succeed())
+		Expect(patches).To(HaveLen(1))
+		Expect(patches[0].GetResource()).To(Equal(deployments))
+		Expect(patches[0].GetNamespace()).To(Equal("default"))
+		Expect(patches[0].GetName()).To(Equal("foo"))
+		Expect(patches[0].GetPatchType()).To(Equal(types.StrategicMergePatchType))
+		Expect(string(patches[0].GetPatch())).To(Equal(`{"spec":{}}`))
+	})
+
+	It("fails on unknown kinds and patch types", func() {
+		f := workloadFix("foo")
+		f.Target.Kind = "Rollout"
+		Expect(applier.Apply(context.Background(), f)).NotTo(Succeed())
+
+		f = workloadFix("foo")
+		f.Type = apiv1.PatchType_UNSPECIFIED_PATCH
+		Expect(applier.Apply(context.Background(), f)).NotTo(Succeed())
+		Expect(patches).To(BeEmpty())
+	})
+})
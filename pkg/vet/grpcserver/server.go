@@ -0,0 +1,161 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcserver implements the VetService gRPC API defined in
+// api/v1/service.proto on top of a list of vetters.
+package grpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vet/watch"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+// Watcher provides the updates streamed by WatchNotes, e.g. a
+// watch.Watcher.
+type Watcher interface {
+	Subscribe() (*report.Report, <-chan *watch.Update, func())
+}
+
+// Server implements apiv1.VetServiceServer.
+type Server struct {
+	apiv1.UnimplementedVetServiceServer
+
+	vetters []vetter.Vetter
+	watcher Watcher
+	opts    vetter.RunOptions
+}
+
+// New returns a Server running vList as configured by opts. The caches of
+// the informers used by the vetters must be synced before the server
+// receives requests. WatchNotes is unimplemented if watcher is nil.
+func New(vList []vetter.Vetter, watcher Watcher, opts vetter.RunOptions) *Server {
+	return &Server{vetters: vList, watcher: watcher, opts: opts}
+}
+
+// ListVetters returns information about all vetters.
+func (s *Server) ListVetters(ctx context.Context, req *apiv1.ListVettersRequest) (*apiv1.ListVettersResponse, error) {
+	resp := &apiv1.ListVettersResponse{}
+	for _, v := range s.vetters {
+		resp.Vetters = append(resp.Vetters, v.Info())
+	}
+	return resp, nil
+}
+
+// checkVetterIDs returns an InvalidArgument error if any of ids isn't the id
+// of a vetter.
+func (s *Server) checkVetterIDs(ids []string) error {
+	known := map[string]bool{}
+	for _, v := range s.vetters {
+		known[v.Info().GetId()] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return status.Errorf(codes.InvalidArgument, "unknown vetter %q", id)
+		}
+	}
+	return nil
+}
+
+// RunVet runs the requested vetters and returns their notes.
+func (s *Server) RunVet(ctx context.Context, req *apiv1.RunVetRequest) (*apiv1.RunVetResponse, error) {
+	if err := s.checkVetterIDs(req.GetVetterIds()); err != nil {
+		return nil, err
+	}
+	f := &report.NoteFilter{Vetters: req.GetVetterIds(), Namespace: req.GetNamespace()}
+	var vList []vetter.Vetter
+	for _, v := range s.vetters {
+		if f.MatchVetter(v.Info()) {
+			vList = append(vList, v)
+		}
+	}
+
+	resp := &apiv1.RunVetResponse{}
+	for _, res := range report.RunContext(ctx, vList, s.opts).Results {
+		vr := &apiv1.VetterResult{Info: res.Info, Notes: f.Filter(res.Info, res.Notes)}
+		if res.Err != nil {
+			vr.Error = res.Err.Error()
+		}
+		resp.Results = append(resp.Results, vr)
+	}
+	return resp, nil
+}
+
+// WatchNotes streams the current notes followed by the notes which appear or
+// are resolved until the client cancels the call.
+func (s *Server) WatchNotes(req *apiv1.WatchNotesRequest, stream apiv1.VetService_WatchNotesServer) error {
+	if s.watcher == nil {
+		return status.Error(codes.Unimplemented, "vetters are not watched")
+	}
+	if err := s.checkVetterIDs(req.GetVetterIds()); err != nil {
+		return err
+	}
+	f := &report.NoteFilter{Vetters: req.GetVetterIds(), Namespace: req.GetNamespace()}
+
+	current, updates, cancel := s.watcher.Subscribe()
+	defer cancel()
+
+	for _, res := range current.Results {
+		if !f.MatchVetter(res.Info) {
+			continue
+		}
+		resp := &apiv1.WatchNotesResponse{Info: res.Info, NewNotes: f.Filter(res.Info, res.Notes)}
+		if res.Err != nil {
+			resp.Error = res.Err.Error()
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case u, ok := <-updates:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "client is not keeping up with updates")
+			}
+			for _, vu := range u.Vetters {
+				if !f.MatchVetter(vu.Info) {
+					continue
+				}
+				resp := &apiv1.WatchNotesResponse{
+					Info:          vu.Info,
+					NewNotes:      f.Filter(vu.Info, vu.New),
+					ResolvedNotes: f.Filter(vu.Info, vu.Resolved),
+					ChangedNotes:  f.Filter(vu.Info, vu.Changed),
+				}
+				if vu.Err != nil {
+					resp.Error = vu.Err.Error()
+				}
+				if resp.Error == "" && len(resp.NewNotes) == 0 && len(resp.ResolvedNotes) == 0 &&
+					len(resp.ChangedNotes) == 0 {
+					continue
+				}
+				if err := stream.Send(resp); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vet/watch"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+type fakeVetter struct {
+	id    string
+	notes []*apiv1.Note
+	err   error
+}
+
+func (v *fakeVetter) Vet() ([]*apiv1.Note, error) { return v.notes, v.err }
+func (v *fakeVetter) Info() *apiv1.Info           { return &apiv1.Info{Id: v.id, Version: "0.1.0"} }
+
+type fakeWatcher struct {
+	current *report.Report
+	updates chan *watch.Update
+}
+
+func (w *fakeWatcher) Subscribe() (*report.Report, <-chan *watch.Update, func()) {
+	return w.current, w.updates, func() {}
+}
+
+func note(id, namespace string) *apiv1.Note {
+	return &apiv1.Note{Id: id, Type: "test", Attr: map[string]string{"namespace": namespace}}
+}
+
+func ids(notes []*apiv1.Note) []string {
+	s := []string{}
+	for _, n := range notes {
+		s = append(s, n.GetId())
+	}
+	return s
+}
+
+var _ = Describe("VetService", func() {
+	var (
+		vList   []vetter.Vetter
+		watcher *fakeWatcher
+		srv     *grpc.Server
+		conn    *grpc.ClientConn
+		client  apiv1.VetServiceClient
+		ctx     context.Context
+		cancel  context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		vList = []vetter.Vetter{
+			&fakeVetter{id: "a", notes: []*apiv1.Note{note("a1", "foo"), note("a2", "bar")}},
+			&fakeVetter{id: "b", err: errors.New("boom")},
+		}
+		watcher = &fakeWatcher{
+			current: report.Run(vList),
+			updates: make(chan *watch.Update, 1),
+		}
+
+		lis := bufconn.Listen(1024 * 1024)
+		srv = grpc.NewServer()
+		apiv1.RegisterVetServiceServer(srv, New(vList, watcher, vetter.RunOptions{}))
+		go srv.Serve(lis)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		var err error
+		conn, err = grpc.DialContext(ctx, "bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		client = apiv1.NewVetServiceClient(conn)
+	})
+
+	AfterEach(func() {
+		cancel()
+		conn.Close()
+		srv.Stop()
+	})
+
+	It("lists vetters", func() {
+		resp, err := client.ListVetters(ctx, &apiv1.ListVettersRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetVetters()).To(HaveLen(2))
+		Expect(resp.GetVetters()[1].GetId()).To(Equal("b"))
+	})
+
+	It("runs vetters", func() {
+		resp, err := client.RunVet(ctx, &apiv1.RunVetRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetResults()).To(HaveLen(2))
+		Expect(ids(resp.GetResults()[0].GetNotes())).To(Equal([]string{"a1", "a2"}))
+		Expect(resp.GetResults()[1].GetError()).To(Equal("boom"))
+	})
+
+	It("runs selected vetters and filters notes by namespace", func() {
+		resp, err := client.RunVet(ctx, &apiv1.RunVetRequest{VetterIds: []string{"a"}, Namespace: "bar"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetResults()).To(HaveLen(1))
+		Expect(ids(resp.GetResults()[0].GetNotes())).To(Equal([]string{"a2"}))
+	})
+
+	It("rejects unknown vetters", func() {
+		_, err := client.RunVet(ctx, &apiv1.RunVetRequest{VetterIds: []string{"c"}})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("streams current notes followed by changes", func() {
+		stream, err := client.WatchNotes(ctx, &apiv1.WatchNotesRequest{Namespace: "foo"})
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetInfo().GetId()).To(Equal("a"))
+		Expect(ids(resp.GetNewNotes())).To(Equal([]string{"a1"}))
+
+		resp, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetInfo().GetId()).To(Equal("b"))
+		Expect(resp.GetError()).To(Equal("boom"))
+
+		watcher.updates <- &watch.Update{Vetters: []*watch.VetterUpdate{
+			{Info: vList[0].Info(), New: []*apiv1.Note{note("a3", "bar")}},
+			{Info: vList[0].Info(), New: []*apiv1.Note{note("a4", "foo")}, Resolved: []*apiv1.Note{note("a1", "foo")}},
+		}}
+		resp, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(resp.GetNewNotes())).To(Equal([]string{"a4"}))
+		Expect(ids(resp.GetResolvedNotes())).To(Equal([]string{"a1"}))
+	})
+
+	It("ends the stream when the subscriber is dropped", func() {
+		stream, err := client.WatchNotes(ctx, &apiv1.WatchNotesRequest{VetterIds: []string{"a"}})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		close(watcher.updates)
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+	})
+})
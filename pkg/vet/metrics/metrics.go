@@ -0,0 +1,195 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exports Prometheus metrics about continuously running
+// vetters and the notes they generate.
+//
+// The following metrics are exported:
+//
+//	istio_vet_notes{vetter,type,level,namespace}   number of current notes
+//	istio_vet_vetter_errors_total{vetter}          number of failed runs
+//	istio_vet_vetter_run_duration_seconds{vetter}  duration of runs
+//	istio_vet_informer_synced{resource}            1 once the cache synced
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+const namespace = "istio_vet"
+
+// ReportSource provides the notes exported as metrics, e.g. a watch.Watcher.
+type ReportSource interface {
+	// Report returns the latest result of every vetter which has run.
+	Report() *report.Report
+}
+
+// Metrics holds the collectors of a vet process in their own registry.
+type Metrics struct {
+	registry    *prometheus.Registry
+	runErrors   *prometheus.CounterVec
+	runDuration *prometheus.HistogramVec
+}
+
+// New returns Metrics exporting the runs of instrumented vetters. The notes
+// and informers are exported once registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		runErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "vetter_errors_total",
+			Help:      "Number of vetter runs which returned an error.",
+		}, []string{"vetter"}),
+		runDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "vetter_run_duration_seconds",
+			Help:      "Duration of vetter runs.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+		}, []string{"vetter"}),
+	}
+	m.registry.MustRegister(m.runErrors, m.runDuration)
+	return m
+}
+
+// Handler returns the HTTP handler serving the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Instrument returns vList with every vetter wrapped to record the duration
+// and errors of its runs.
+func (m *Metrics) Instrument(vList []vetter.Vetter) []vetter.Vetter {
+	out := make([]vetter.Vetter, len(vList))
+	for i, v := range vList {
+		out[i] = &instrumentedVetter{Vetter: v, metrics: m}
+	}
+	return out
+}
+
+type instrumentedVetter struct {
+	vetter.Vetter
+	metrics *Metrics
+}
+
+func (v *instrumentedVetter) Unwrap() vetter.Vetter {
+	return v.Vetter
+}
+
+func (v *instrumentedVetter) Vet() ([]*apiv1.Note, error) {
+	return v.VetContext(context.Background())
+}
+
+func (v *instrumentedVetter) VetContext(ctx context.Context) ([]*apiv1.Note, error) {
+	id := v.Info().GetId()
+	start := time.Now()
+	notes, err := vetter.VetContext(ctx, v.Vetter)
+	v.metrics.runDuration.WithLabelValues(id).Observe(time.Since(start).Seconds())
+	if err != nil {
+		v.metrics.runErrors.WithLabelValues(id).Inc()
+	}
+	return notes, err
+}
+
+// RegisterReport exports the number of notes in the reports of s, by
+// vetter, type, level and namespace.
+func (m *Metrics) RegisterReport(s ReportSource) error {
+	return m.registry.Register(&notesCollector{source: s})
+}
+
+// RegisterInformers exports whether the caches of the informers for
+// resources synced.
+func (m *Metrics) RegisterInformers(f vetter.ResourceListGetter, resources []string) error {
+	c := &syncedCollector{informers: map[string]cache.SharedIndexInformer{}}
+	for _, r := range resources {
+		inf, err := vetter.Informer(f, r)
+		if err != nil {
+			return err
+		}
+		c.informers[r] = inf
+	}
+	return m.registry.Register(c)
+}
+
+var notesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "notes"),
+	"Number of notes currently generated by vetters.",
+	[]string{"vetter", "type", "level", "namespace"}, nil)
+
+type notesCollector struct {
+	source ReportSource
+}
+
+func (c *notesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- notesDesc
+}
+
+type noteKey struct {
+	vetter, noteType, level, namespace string
+}
+
+func (c *notesCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := map[noteKey]int{}
+	for _, res := range c.source.Report().Results {
+		for _, n := range res.Notes {
+			k := noteKey{
+				vetter:    res.Info.GetId(),
+				noteType:  n.GetType(),
+				level:     strings.ToLower(n.GetLevel().String()),
+				namespace: n.GetAttr()["namespace"],
+			}
+			counts[k]++
+		}
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(notesDesc, prometheus.GaugeValue,
+			float64(count), k.vetter, k.noteType, k.level, k.namespace)
+	}
+}
+
+var syncedDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "informer_synced"),
+	"Whether the cache of the informer for a resource synced.",
+	[]string{"resource"}, nil)
+
+type syncedCollector struct {
+	informers map[string]cache.SharedIndexInformer
+}
+
+func (c *syncedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- syncedDesc
+}
+
+func (c *syncedCollector) Collect(ch chan<- prometheus.Metric) {
+	for r, inf := range c.informers {
+		synced := 0.0
+		if inf.HasSynced() {
+			synced = 1
+		}
+		ch <- prometheus.MustNewConstMetric(syncedDesc, prometheus.GaugeValue, synced, r)
+	}
+}
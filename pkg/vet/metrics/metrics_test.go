@@ -0,0 +1,135 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	"k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+type fakeVetter struct {
+	id    string
+	notes []*apiv1.Note
+	err   error
+}
+
+func (v *fakeVetter) Vet() ([]*apiv1.Note, error) { return v.notes, v.err }
+func (v *fakeVetter) Info() *apiv1.Info           { return &apiv1.Info{Id: v.id, Version: "0.1.0"} }
+
+type fakeSource struct {
+	report *report.Report
+}
+
+func (r *fakeSource) Report() *report.Report { return r.report }
+
+type factory struct {
+	k8s   informers.SharedInformerFactory
+	istio istioinformer.SharedInformerFactory
+}
+
+func (f *factory) K8s() informers.SharedInformerFactory       { return f.k8s }
+func (f *factory) Istio() istioinformer.SharedInformerFactory { return f.istio }
+
+func note(t string, level apiv1.NoteLevel, namespace string) *apiv1.Note {
+	return &apiv1.Note{Type: t, Level: level, Attr: map[string]string{"namespace": namespace}}
+}
+
+func scrape(m *Metrics) string {
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	Expect(rec.Code).To(Equal(200))
+	b, err := ioutil.ReadAll(rec.Body)
+	Expect(err).NotTo(HaveOccurred())
+	return string(b)
+}
+
+var _ = Describe("Metrics", func() {
+	var m *Metrics
+
+	BeforeEach(func() {
+		m = New()
+	})
+
+	It("exports the number of notes", func() {
+		r := &fakeSource{report: &report.Report{Results: []*report.VetterResult{{
+			Info: &apiv1.Info{Id: "a"},
+			Notes: []*apiv1.Note{
+				note("foo", apiv1.NoteLevel_WARNING, "default"),
+				note("foo", apiv1.NoteLevel_WARNING, "default"),
+				note("bar", apiv1.NoteLevel_ERROR, "other"),
+			},
+		}}}}
+		Expect(m.RegisterReport(r)).To(Succeed())
+
+		out := scrape(m)
+		Expect(out).To(ContainSubstring(`istio_vet_notes{level="warning",namespace="default",type="foo",vetter="a"} 2`))
+		Expect(out).To(ContainSubstring(`istio_vet_notes{level="error",namespace="other",type="bar",vetter="a"} 1`))
+
+		By("dropping resolved notes")
+		r.report = &report.Report{}
+		Expect(scrape(m)).NotTo(ContainSubstring("istio_vet_notes{"))
+	})
+
+	It("exports runs of instrumented vetters", func() {
+		vList := m.Instrument([]vetter.Vetter{
+			&fakeVetter{id: "a", notes: []*apiv1.Note{note("foo", apiv1.NoteLevel_INFO, "")}},
+			&fakeVetter{id: "b", err: errors.New("boom")},
+		})
+		r := report.Run(vList)
+		Expect(r.Results[0].Info.GetId()).To(Equal("a"))
+		Expect(r.Results[0].Notes).To(HaveLen(1))
+		Expect(r.Results[1].Err).To(HaveOccurred())
+
+		out := scrape(m)
+		Expect(out).To(ContainSubstring(`istio_vet_vetter_errors_total{vetter="b"} 1`))
+		Expect(out).NotTo(ContainSubstring(`istio_vet_vetter_errors_total{vetter="a"}`))
+		Expect(out).To(ContainSubstring(`istio_vet_vetter_run_duration_seconds_count{vetter="a"} 1`))
+		Expect(out).To(ContainSubstring(`istio_vet_vetter_run_duration_seconds_count{vetter="b"} 1`))
+	})
+
+	It("exports the sync status of informers", func() {
+		f := &factory{
+			k8s:   informers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0),
+			istio: istioinformer.NewSharedInformerFactory(istiofake.NewSimpleClientset(), 0),
+		}
+		Expect(m.RegisterInformers(f, []string{vetter.Pods, vetter.VirtualServices})).To(Succeed())
+		Expect(scrape(m)).To(ContainSubstring(`istio_vet_informer_synced{resource="pods"} 0`))
+
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		f.k8s.Start(stopCh)
+		f.k8s.WaitForCacheSync(stopCh)
+		out := scrape(m)
+		Expect(out).To(ContainSubstring(`istio_vet_informer_synced{resource="pods"} 1`))
+		Expect(out).To(ContainSubstring(`istio_vet_informer_synced{resource="virtualservices"} 0`))
+
+		Expect(m.RegisterInformers(f, []string{"unknown"})).NotTo(Succeed())
+	})
+})
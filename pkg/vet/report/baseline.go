@@ -0,0 +1,132 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+var noteUnmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// ReadJSON reads a report written by WriteJSON, e.g. a baseline. Notes are
+// read with their rendered summary and message.
+func ReadJSON(r io.Reader) (*Report, error) {
+	var out reportOutput
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	for _, vo := range out.Vetters {
+		res := &VetterResult{
+			Info:       &apiv1.Info{Id: vo.ID, Version: vo.Version},
+			Suppressed: vo.Suppressed,
+		}
+		if vo.Error != "" {
+			res.Err = errors.New(vo.Error)
+		}
+		for _, b := range vo.Notes {
+			n := &apiv1.Note{}
+			if err := noteUnmarshaler.Unmarshal(b, n); err != nil {
+				return nil, fmt.Errorf("invalid note of vetter %s: %s", vo.ID, err)
+			}
+			res.Notes = append(res.Notes, n)
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
+
+// ReadBaseline reads a baseline written by WriteBaseline.
+func ReadBaseline(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := ReadJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %s: %s", path, err)
+	}
+	return r, nil
+}
+
+// WriteBaseline writes r as JSON to path, to be compared against by Diff.
+func WriteBaseline(path string, r *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteJSON(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Diff returns a report with the notes of r not in baseline, compared by id.
+// If resolved is set, the notes of baseline not in r are returned in
+// Resolved, for the vetters which ran successfully in r.
+func (r *Report) Diff(baseline *Report, resolved bool) *Report {
+	known := map[string]bool{}
+	for _, res := range baseline.Results {
+		for _, n := range res.Notes {
+			known[n.GetId()] = true
+		}
+	}
+	current := map[string]bool{}
+	ran := map[string]bool{}
+	d := &Report{}
+	for _, res := range r.Results {
+		dres := &VetterResult{Info: res.Info, Err: res.Err, Suppressed: res.Suppressed}
+		for _, n := range res.Notes {
+			current[n.GetId()] = true
+			if !known[n.GetId()] {
+				dres.Notes = append(dres.Notes, n)
+			}
+		}
+		if res.Err == nil {
+			ran[res.Info.GetId()] = true
+		}
+		d.Results = append(d.Results, dres)
+	}
+	if !resolved {
+		return d
+	}
+	for _, res := range baseline.Results {
+		if !ran[res.Info.GetId()] {
+			continue
+		}
+		var notes []*apiv1.Note
+		for _, n := range res.Notes {
+			if !current[n.GetId()] {
+				notes = append(notes, n)
+			}
+		}
+		if len(notes) > 0 {
+			d.Resolved = append(d.Resolved, &VetterResult{Info: res.Info, Notes: notes})
+		}
+	}
+	return d
+}
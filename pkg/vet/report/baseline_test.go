@@ -0,0 +1,112 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+var _ = Describe("Baseline", func() {
+	It("reads back a report written as JSON", func() {
+		var b bytes.Buffer
+		Expect(WriteJSON(&b, testReport())).To(Succeed())
+		r, err := ReadJSON(&b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Results).To(HaveLen(2))
+		Expect(r.Results[0].Info.GetId()).To(Equal("applabel"))
+		Expect(r.Results[0].Info.GetVersion()).To(Equal("0.1.0"))
+		Expect(r.Results[0].Notes).To(HaveLen(1))
+		n := r.Results[0].Notes[0]
+		Expect(n.GetId()).To(Equal("abc"))
+		Expect(n.GetLevel()).To(Equal(apiv1.NoteLevel_WARNING))
+		Expect(n.GetSummary()).To(Equal("Missing app label - foo"))
+		Expect(r.Results[1].Err).To(MatchError("boom"))
+	})
+
+	It("writes and reads baseline files", func() {
+		dir, err := os.MkdirTemp("", "baseline")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "baseline.json")
+		Expect(WriteBaseline(path, testReport())).To(Succeed())
+		r, err := ReadBaseline(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Results).To(HaveLen(2))
+
+		Expect(os.WriteFile(path, []byte("{"), 0644)).To(Succeed())
+		_, err = ReadBaseline(path)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reports only the notes not in the baseline", func() {
+		baseline := testReport()
+		r := testReport()
+		r.Results[0].Notes = append(r.Results[0].Notes, &apiv1.Note{
+			Id:      "def",
+			Type:    "missing-app-label",
+			Summary: "Missing app label - baz",
+			Level:   apiv1.NoteLevel_ERROR,
+		})
+		d := r.Diff(baseline, false)
+		Expect(d.Results).To(HaveLen(2))
+		Expect(d.Results[0].Notes).To(HaveLen(1))
+		Expect(d.Results[0].Notes[0].GetId()).To(Equal("def"))
+		Expect(d.Results[1].Err).To(MatchError("boom"))
+		Expect(d.Resolved).To(BeEmpty())
+		Expect(d.MaxLevel()).To(Equal(apiv1.NoteLevel_ERROR))
+
+		Expect(testReport().Diff(baseline, false).MaxLevel()).To(Equal(apiv1.NoteLevel_UNUSED))
+	})
+
+	It("reports the resolved notes of vetters which ran", func() {
+		baseline := testReport()
+		baseline.Results[1].Err = nil
+		baseline.Results[1].Notes = []*apiv1.Note{&apiv1.Note{Id: "xyz"}}
+		r := testReport()
+		r.Results[0].Notes = nil
+
+		d := r.Diff(baseline, true)
+		Expect(d.Results[0].Notes).To(BeEmpty())
+		// The broken vetter failed, its notes may still be there.
+		Expect(d.Resolved).To(HaveLen(1))
+		Expect(d.Resolved[0].Info.GetId()).To(Equal("applabel"))
+		Expect(d.Resolved[0].Notes[0].GetId()).To(Equal("abc"))
+
+		var b bytes.Buffer
+		Expect(WriteText(&b, d)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("Resolved: Missing app label - foo (vetter \"applabel\", id abc)\n"))
+
+		b.Reset()
+		Expect(WriteJSON(&b, d)).To(Succeed())
+		var out map[string][]map[string]interface{}
+		Expect(json.Unmarshal(b.Bytes(), &out)).To(Succeed())
+		Expect(out["resolved"]).To(HaveLen(1))
+		Expect(out["resolved"][0]["id"]).To(Equal("applabel"))
+
+		b.Reset()
+		Expect(WriteJSON(&b, testReport())).To(Succeed())
+		Expect(b.String()).NotTo(ContainSubstring("resolved"))
+	})
+})
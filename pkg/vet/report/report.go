@@ -0,0 +1,351 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report collects the notes generated by vetters and renders them
+// as human readable text or as machine readable JSON and YAML.
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+// Supported output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Formats lists the output formats accepted by Write.
+var Formats = []string{FormatText, FormatJSON, FormatYAML}
+
+// VetterResult holds the outcome of running a single vetter.
+type VetterResult struct {
+	Info  *apiv1.Info
+	Notes []*apiv1.Note
+	Err   error
+	// Suppressed is the number of notes suppressed by annotations
+	Suppressed int
+}
+
+// Report is the outcome of a vet run, one result per vetter in the order the
+// vetters were run.
+type Report struct {
+	Results []*VetterResult
+
+	// Resolved holds the notes of a baseline which are not generated
+	// anymore, see Diff
+	Resolved []*VetterResult
+}
+
+// Run runs every vetter in vList once and collects the generated notes.
+func Run(vList []vetter.Vetter) *Report {
+	return RunContext(context.Background(), vList, vetter.RunOptions{})
+}
+
+// RunContext runs the vetters in vList concurrently as configured by opts
+// and collects the generated notes. Vetters which panic, time out or are
+// cancelled by ctx report an error.
+func RunContext(ctx context.Context, vList []vetter.Vetter, opts vetter.RunOptions) *Report {
+	r := &Report{}
+	for i, res := range vetter.RunAll(ctx, vList, opts) {
+		r.Results = append(r.Results, &VetterResult{
+			Info:       vList[i].Info(),
+			Notes:      res.Notes,
+			Err:        res.Err,
+			Suppressed: vetter.Suppressed(vList[i]),
+		})
+	}
+	return r
+}
+
+// MaxLevel returns the highest level of the notes in the report, or
+// NoteLevel_UNUSED if there are none.
+func (r *Report) MaxLevel() apiv1.NoteLevel {
+	max := apiv1.NoteLevel_UNUSED
+	for _, res := range r.Results {
+		for _, n := range res.Notes {
+			if n.GetLevel() > max {
+				max = n.GetLevel()
+			}
+		}
+	}
+	return max
+}
+
+// Errors returns the number of vetters which reported an error.
+func (r *Report) Errors() int {
+	var count int
+	for _, res := range r.Results {
+		if res.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// NoteFilter selects notes. Empty fields match any note.
+type NoteFilter struct {
+	// Vetters are the ids of the vetters whose notes are selected.
+	Vetters   []string
+	Namespace string
+	Level     apiv1.NoteLevel
+	Type      string
+}
+
+// MatchVetter returns true if the filter selects notes of the vetter.
+func (f *NoteFilter) MatchVetter(vetter *apiv1.Info) bool {
+	if len(f.Vetters) == 0 {
+		return true
+	}
+	for _, id := range f.Vetters {
+		if id == vetter.GetId() {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the filter selects note n generated by vetter.
+func (f *NoteFilter) Match(vetter *apiv1.Info, n *apiv1.Note) bool {
+	if !f.MatchVetter(vetter) {
+		return false
+	}
+	if f.Namespace != "" && f.Namespace != n.GetAttr()["namespace"] {
+		return false
+	}
+	if f.Level != apiv1.NoteLevel_UNUSED && f.Level != n.GetLevel() {
+		return false
+	}
+	if f.Type != "" && f.Type != n.GetType() {
+		return false
+	}
+	return true
+}
+
+// Filter returns the notes selected by f.
+func (f *NoteFilter) Filter(vetter *apiv1.Info, notes []*apiv1.Note) []*apiv1.Note {
+	var selected []*apiv1.Note
+	for _, n := range notes {
+		if f.Match(vetter, n) {
+			selected = append(selected, n)
+		}
+	}
+	return selected
+}
+
+// Render returns a copy of the note with "${var}" template strings in the
+// summary and message substituted from the note attributes.
+func Render(n *apiv1.Note) *apiv1.Note {
+	var ts []string
+	for k, v := range n.GetAttr() {
+		ts = append(ts, "${"+k+"}", v)
+	}
+	r := strings.NewReplacer(ts...)
+	rn := proto.Clone(n).(*apiv1.Note)
+	rn.Summary = r.Replace(n.GetSummary())
+	rn.Msg = r.Replace(n.GetMsg())
+	return rn
+}
+
+// CheckFormat returns an error if format is not one of Formats.
+func CheckFormat(format string) error {
+	for _, f := range Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, must be one of: %s",
+		format, strings.Join(Formats, ", "))
+}
+
+// Write renders the report to w in the given format.
+func Write(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatText:
+		return WriteText(w, r)
+	case FormatJSON:
+		return WriteJSON(w, r)
+	case FormatYAML:
+		return WriteYAML(w, r)
+	}
+	return CheckFormat(format)
+}
+
+// WriteNote renders a single note as human readable text.
+func WriteNote(w io.Writer, n *apiv1.Note) {
+	rn := Render(n)
+	writeNote(w, rn.GetLevel().String(), rn.GetSummary(), rn.GetMsg())
+	if rn.GetRemediation() == "" && rn.GetCode() == "" && rn.GetFix() == nil {
+		return
+	}
+	if rn.GetRemediation() != "" {
+		fmt.Fprintf(w, "Remediation: %s\n", rn.GetRemediation())
+	}
+	if f := rn.GetFix(); f != nil {
+		fmt.Fprintf(w, "Fix: %s, run \"vet fix\" to print or apply it\n", f.GetDescription())
+	}
+	if rn.GetCode() != "" {
+		fmt.Fprintf(w, "More information: run \"vet explain %s\"", rn.GetCode())
+		if rn.GetDocsUrl() != "" {
+			fmt.Fprintf(w, " or see %s", rn.GetDocsUrl())
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
+}
+
+func writeNote(w io.Writer, level, summary, msg string) {
+	if len(summary) > 0 {
+		fmt.Fprintf(w, "%s\n", summary)
+		if len(msg) > 0 {
+			fmt.Fprintf(w, "%s\n", strings.Repeat("=", len(summary)))
+		} else {
+			fmt.Fprintln(w)
+		}
+	}
+	if len(msg) > 0 {
+		fmt.Fprintf(w, "%s: %s\n\n", level, msg)
+	}
+}
+
+// WriteText renders the report as human readable text.
+func WriteText(w io.Writer, r *Report) error {
+	for _, res := range r.Results {
+		if res.Err != nil {
+			fmt.Fprintf(w, "Vetter: \"%s\" reported error: %s\n", res.Info.GetId(), res.Err)
+			continue
+		}
+		if len(res.Notes) == 0 {
+			fmt.Fprintf(w, "Vetter \"%s\" ran successfully and generated no notes\n\n", res.Info.GetId())
+		}
+		for _, n := range res.Notes {
+			WriteNote(w, n)
+		}
+		if res.Suppressed > 0 {
+			fmt.Fprintf(w, "Vetter \"%s\" suppressed %d note(s) by annotation\n\n", res.Info.GetId(), res.Suppressed)
+		}
+	}
+	for _, res := range r.Resolved {
+		for _, n := range res.Notes {
+			fmt.Fprintf(w, "Resolved: %s (vetter \"%s\", id %s)\n", Render(n).GetSummary(), res.Info.GetId(), n.GetId())
+		}
+	}
+	if len(r.Resolved) > 0 {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// vetterOutput is the stable JSON/YAML schema for a single vetter result.
+type vetterOutput struct {
+	ID      string            `json:"id"`
+	Version string            `json:"version"`
+	Notes   []json.RawMessage `json:"notes"`
+	Error   string            `json:"error,omitempty"`
+	// Suppressed is the number of notes suppressed by annotations
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+type reportOutput struct {
+	Vetters  []vetterOutput `json:"vetters"`
+	Resolved []vetterOutput `json:"resolved,omitempty"`
+}
+
+var noteMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// MarshalNote encodes a note as JSON with its summary and message rendered.
+func MarshalNote(n *apiv1.Note) (json.RawMessage, error) {
+	b, err := noteMarshaler.Marshal(Render(n))
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(b), nil
+}
+
+func marshalJSON(r *Report) ([]byte, error) {
+	var out reportOutput
+	var err error
+	if out.Vetters, err = vetterOutputs(r.Results); err != nil {
+		return nil, err
+	}
+	if len(r.Resolved) > 0 {
+		if out.Resolved, err = vetterOutputs(r.Resolved); err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+func vetterOutputs(results []*VetterResult) ([]vetterOutput, error) {
+	out := []vetterOutput{}
+	for _, res := range results {
+		vo := vetterOutput{
+			ID:         res.Info.GetId(),
+			Version:    res.Info.GetVersion(),
+			Notes:      []json.RawMessage{},
+			Suppressed: res.Suppressed,
+		}
+		if res.Err != nil {
+			vo.Error = res.Err.Error()
+		}
+		for _, n := range res.Notes {
+			b, err := MarshalNote(n)
+			if err != nil {
+				return nil, err
+			}
+			vo.Notes = append(vo.Notes, b)
+		}
+		out = append(out, vo)
+	}
+	return out, nil
+}
+
+// WriteJSON renders the report as JSON. Notes are encoded with their proto
+// field names and have their summary and message rendered.
+func WriteJSON(w io.Writer, r *Report) error {
+	b, err := marshalJSON(r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// WriteYAML renders the report as YAML using the same schema as WriteJSON.
+func WriteYAML(w io.Writer, r *Report) error {
+	b, err := marshalJSON(r)
+	if err != nil {
+		return err
+	}
+	y, err := yaml.JSONToYAML(b)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(y)
+	return err
+}
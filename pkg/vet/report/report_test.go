@@ -0,0 +1,162 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/ghodss/yaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+func testReport() *Report {
+	return &Report{
+		Results: []*VetterResult{
+			&VetterResult{
+				Info: &apiv1.Info{Id: "applabel", Version: "0.1.0"},
+				Notes: []*apiv1.Note{
+					&apiv1.Note{
+						Id:      "abc",
+						Type:    "missing-app-label",
+						Summary: "Missing app label - ${pod_name}",
+						Msg:     "The pod ${pod_name} in namespace ${namespace} is missing a label.",
+						Level:   apiv1.NoteLevel_WARNING,
+						Attr: map[string]string{
+							"pod_name":  "foo",
+							"namespace": "bar",
+						},
+					},
+				},
+			},
+			&VetterResult{
+				Info: &apiv1.Info{Id: "broken", Version: "0.2.0"},
+				Err:  errors.New("boom"),
+			},
+		},
+	}
+}
+
+var _ = Describe("Report", func() {
+	It("renders template strings without modifying the note", func() {
+		n := testReport().Results[0].Notes[0]
+		rn := Render(n)
+		Expect(rn.Summary).To(Equal("Missing app label - foo"))
+		Expect(rn.Msg).To(Equal("The pod foo in namespace bar is missing a label."))
+		Expect(n.Summary).To(Equal("Missing app label - ${pod_name}"))
+	})
+
+	It("summarizes note levels and vetter errors", func() {
+		r := testReport()
+		Expect(r.MaxLevel()).To(Equal(apiv1.NoteLevel_WARNING))
+		Expect(r.Errors()).To(Equal(1))
+		Expect((&Report{}).MaxLevel()).To(Equal(apiv1.NoteLevel_UNUSED))
+		Expect((&Report{}).Errors()).To(Equal(0))
+	})
+
+	It("writes the remediation of notes as text", func() {
+		n := testReport().Results[0].Notes[0]
+		var b bytes.Buffer
+		WriteNote(&b, n)
+		Expect(b.String()).NotTo(ContainSubstring("Remediation"))
+
+		n.Code = "IV0301"
+		n.Remediation = "Add an app label."
+		n.DocsUrl = "https://example.com/docs"
+		b.Reset()
+		WriteNote(&b, n)
+		Expect(b.String()).To(HaveSuffix("WARNING: The pod foo in namespace bar is missing a label.\n\n" +
+			"Remediation: Add an app label.\n" +
+			"More information: run \"vet explain IV0301\" or see https://example.com/docs\n\n"))
+	})
+
+	It("writes the fix of notes as text", func() {
+		n := testReport().Results[0].Notes[0]
+		n.Fix = &apiv1.Fix{Description: "Add label app=foo to Pod foo"}
+		var b bytes.Buffer
+		WriteNote(&b, n)
+		Expect(b.String()).To(HaveSuffix("Fix: Add label app=foo to Pod foo, run \"vet fix\" to print or apply it\n\n"))
+	})
+
+	It("rejects unknown formats", func() {
+		Expect(CheckFormat("json")).To(Succeed())
+		Expect(CheckFormat("xml")).NotTo(Succeed())
+		Expect(Write(&bytes.Buffer{}, testReport(), "xml")).NotTo(Succeed())
+	})
+
+	It("writes the number of suppressed notes", func() {
+		r := testReport()
+		r.Results[0].Suppressed = 2
+		var b bytes.Buffer
+		Expect(WriteText(&b, r)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("Vetter \"applabel\" suppressed 2 note(s) by annotation\n"))
+
+		b.Reset()
+		Expect(Write(&b, r, FormatJSON)).To(Succeed())
+		var out map[string][]map[string]interface{}
+		Expect(json.Unmarshal(b.Bytes(), &out)).To(Succeed())
+		Expect(out["vetters"][0]).To(HaveKeyWithValue("suppressed", BeEquivalentTo(2)))
+		Expect(out["vetters"][1]).NotTo(HaveKey("suppressed"))
+	})
+
+	It("writes notes and errors grouped by vetter as JSON", func() {
+		var b bytes.Buffer
+		Expect(Write(&b, testReport(), FormatJSON)).To(Succeed())
+
+		var out map[string][]map[string]interface{}
+		Expect(json.Unmarshal(b.Bytes(), &out)).To(Succeed())
+		Expect(out["vetters"]).To(HaveLen(2))
+
+		v := out["vetters"][0]
+		Expect(v["id"]).To(Equal("applabel"))
+		Expect(v["version"]).To(Equal("0.1.0"))
+		Expect(v).NotTo(HaveKey("error"))
+		notes := v["notes"].([]interface{})
+		Expect(notes).To(HaveLen(1))
+		n := notes[0].(map[string]interface{})
+		Expect(n["id"]).To(Equal("abc"))
+		Expect(n["type"]).To(Equal("missing-app-label"))
+		Expect(n["level"]).To(Equal("WARNING"))
+		Expect(n["summary"]).To(Equal("Missing app label - foo"))
+		Expect(n["attr"]).To(HaveKeyWithValue("pod_name", "foo"))
+
+		v = out["vetters"][1]
+		Expect(v["error"]).To(Equal("boom"))
+		Expect(v["notes"]).To(BeEmpty())
+	})
+
+	It("writes the same schema as YAML", func() {
+		var j, y bytes.Buffer
+		Expect(Write(&j, testReport(), FormatJSON)).To(Succeed())
+		Expect(Write(&y, testReport(), FormatYAML)).To(Succeed())
+		fromYAML, err := yaml.YAMLToJSON(y.Bytes())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fromYAML).To(MatchJSON(j.Bytes()))
+	})
+
+	It("writes text output", func() {
+		var b bytes.Buffer
+		Expect(Write(&b, testReport(), FormatText)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("Missing app label - foo\n"))
+		Expect(b.String()).To(ContainSubstring("WARNING: The pod foo in namespace bar"))
+		Expect(b.String()).To(ContainSubstring("Vetter: \"broken\" reported error: boom"))
+	})
+})
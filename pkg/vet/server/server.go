@@ -0,0 +1,180 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server exposes the notes of continuously running vetters over a
+// REST API.
+//
+// The following endpoints are served:
+//
+//	/v1/notes    notes of all vetters, filtered by the optional query
+//	             parameters "namespace", "level", "type" and "vetter"
+//	/v1/vetters  id and version of every vetter
+//	/healthz     always succeeds while the server is running
+//	/readyz      succeeds once the resource caches synced and all vetters ran
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+// Source provides the vetter results served by the API, e.g. a
+// watch.Watcher.
+type Source interface {
+	// Report returns the latest result of every vetter which has run.
+	Report() *report.Report
+	// Vetters returns information about all vetters.
+	Vetters() []*apiv1.Info
+	// Ready returns true once all vetters have run at least once.
+	Ready() bool
+}
+
+type server struct {
+	source Source
+}
+
+// NewHandler returns the HTTP handler serving the API for source.
+func NewHandler(source Source) http.Handler {
+	s := &server{source: source}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/notes", s.notes)
+	mux.HandleFunc("/v1/vetters", s.vetters)
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	return mux
+}
+
+// ParseNoteFilter reads a note filter from the query parameters
+// "namespace", "level", "type" and "vetter". Levels are case insensitive.
+func ParseNoteFilter(r *http.Request) (*report.NoteFilter, error) {
+	q := r.URL.Query()
+	f := &report.NoteFilter{
+		Namespace: q.Get("namespace"),
+		Type:      q.Get("type"),
+	}
+	if v := q.Get("vetter"); v != "" {
+		f.Vetters = []string{v}
+	}
+	if l := q.Get("level"); l != "" {
+		v, ok := apiv1.NoteLevel_value[strings.ToUpper(l)]
+		if !ok || apiv1.NoteLevel(v) == apiv1.NoteLevel_UNUSED {
+			return nil, fmt.Errorf("invalid level %q", l)
+		}
+		f.Level = apiv1.NoteLevel(v)
+	}
+	return f, nil
+}
+
+type notesResponse struct {
+	Notes []json.RawMessage `json:"notes"`
+}
+
+type vettersResponse struct {
+	Vetters []json.RawMessage `json:"vetters"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		glog.Errorf("Failed to encode response: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return false
+	}
+	return true
+}
+
+func (s *server) notes(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	f, err := ParseNoteFilter(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	resp := notesResponse{Notes: []json.RawMessage{}}
+	for _, res := range s.source.Report().Results {
+		for _, n := range res.Notes {
+			if !f.Match(res.Info, n) {
+				continue
+			}
+			b, err := report.MarshalNote(n)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+				return
+			}
+			resp.Notes = append(resp.Notes, b)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+var infoMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+func (s *server) vetters(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	resp := vettersResponse{Vetters: []json.RawMessage{}}
+	for _, info := range s.source.Vetters() {
+		b, err := infoMarshaler.Marshal(info)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		resp.Vetters = append(resp.Vetters, b)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	if !s.source.Ready() {
+		writeJSON(w, http.StatusServiceUnavailable, statusResponse{Status: "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{Status: "ok"})
+}
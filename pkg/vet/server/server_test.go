@@ -0,0 +1,143 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+type fakeSource struct {
+	report *report.Report
+	ready  bool
+}
+
+func (s *fakeSource) Report() *report.Report { return s.report }
+func (s *fakeSource) Ready() bool            { return s.ready }
+func (s *fakeSource) Vetters() []*apiv1.Info {
+	var infos []*apiv1.Info
+	for _, r := range s.report.Results {
+		infos = append(infos, r.Info)
+	}
+	return infos
+}
+
+func note(id, noteType, namespace string, level apiv1.NoteLevel) *apiv1.Note {
+	return &apiv1.Note{
+		Id:      id,
+		Type:    noteType,
+		Summary: "Summary for ${namespace}",
+		Level:   level,
+		Attr:    map[string]string{"namespace": namespace},
+	}
+}
+
+var _ = Describe("Server", func() {
+	var (
+		source  *fakeSource
+		handler http.Handler
+	)
+
+	get := func(path string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		var body map[string]interface{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		return rec.Code, body
+	}
+
+	noteIDs := func(path string) []string {
+		code, body := get(path)
+		Expect(code).To(Equal(http.StatusOK))
+		ids := []string{}
+		for _, n := range body["notes"].([]interface{}) {
+			ids = append(ids, n.(map[string]interface{})["id"].(string))
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		source = &fakeSource{report: &report.Report{Results: []*report.VetterResult{
+			&report.VetterResult{
+				Info: &apiv1.Info{Id: "applabel", Version: "0.1.0"},
+				Notes: []*apiv1.Note{
+					note("1", "missing-app-label", "foo", apiv1.NoteLevel_WARNING),
+					note("2", "missing-app-label", "bar", apiv1.NoteLevel_WARNING),
+				},
+			},
+			&report.VetterResult{
+				Info:  &apiv1.Info{Id: "serviceassociation", Version: "0.2.0"},
+				Notes: []*apiv1.Note{note("3", "multiple-service-association", "foo", apiv1.NoteLevel_ERROR)},
+			},
+		}}}
+		handler = NewHandler(source)
+	})
+
+	It("serves all rendered notes", func() {
+		Expect(noteIDs("/v1/notes")).To(Equal([]string{"1", "2", "3"}))
+		_, body := get("/v1/notes")
+		n := body["notes"].([]interface{})[0].(map[string]interface{})
+		Expect(n["summary"]).To(Equal("Summary for foo"))
+		Expect(n["level"]).To(Equal("WARNING"))
+	})
+
+	It("filters notes", func() {
+		Expect(noteIDs("/v1/notes?namespace=foo")).To(Equal([]string{"1", "3"}))
+		Expect(noteIDs("/v1/notes?level=error")).To(Equal([]string{"3"}))
+		Expect(noteIDs("/v1/notes?type=missing-app-label&namespace=bar")).To(Equal([]string{"2"}))
+		Expect(noteIDs("/v1/notes?vetter=serviceassociation")).To(Equal([]string{"3"}))
+		Expect(noteIDs("/v1/notes?namespace=none")).To(BeEmpty())
+	})
+
+	It("rejects invalid levels", func() {
+		code, body := get("/v1/notes?level=fatal")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(body["error"]).To(ContainSubstring("fatal"))
+	})
+
+	It("serves vetters", func() {
+		code, body := get("/v1/vetters")
+		Expect(code).To(Equal(http.StatusOK))
+		vetters := body["vetters"].([]interface{})
+		Expect(vetters).To(HaveLen(2))
+		Expect(vetters[1]).To(Equal(map[string]interface{}{"id": "serviceassociation", "version": "0.2.0"}))
+	})
+
+	It("is ready once the source is", func() {
+		code, _ := get("/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get("/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		source.ready = true
+		code, _ = get("/readyz")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("only allows GET", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/notes", nil))
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -0,0 +1,217 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vetreport persists vet results in the cluster as VetReport and
+// NamespaceVetReport custom resources, defined in
+// install/kubernetes/vetreport-crd.yaml.
+//
+// The cluster scoped VetReport holds the notes of all vetters. A
+// NamespaceVetReport of the same name is written into every namespace with
+// notes, holding the notes whose "namespace" attribute refers to it. The
+// status of both holds the number of notes per level and per vetter.
+package vetreport
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/dynamic"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+// Group and version of the report resources.
+const (
+	Group   = "vet.aspenmesh.io"
+	Version = "v1alpha1"
+)
+
+var (
+	// VetReports is the cluster scoped resource holding all notes.
+	VetReports = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "vetreports"}
+	// NamespaceVetReports is the namespaced resource holding the notes of a
+	// namespace.
+	NamespaceVetReports = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "namespacevetreports"}
+)
+
+// Writer writes reports into the report resources with a given name.
+type Writer struct {
+	client dynamic.Interface
+	name   string
+}
+
+// NewWriter returns a Writer for the report resources named name.
+func NewWriter(client dynamic.Interface, name string) *Writer {
+	return &Writer{client: client, name: name}
+}
+
+type vetterStatus struct {
+	ID         string `json:"id"`
+	Version    string `json:"version"`
+	Notes      int    `json:"notes"`
+	Suppressed int    `json:"suppressed,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type status struct {
+	LastRunTime string         `json:"lastRunTime"`
+	Info        int            `json:"info"`
+	Warning     int            `json:"warning"`
+	Error       int            `json:"error"`
+	Vetters     []vetterStatus `json:"vetters"`
+}
+
+type content struct {
+	Notes  []json.RawMessage `json:"notes"`
+	Status status            `json:"status"`
+}
+
+func newContent(now time.Time) *content {
+	return &content{
+		Notes:  []json.RawMessage{},
+		Status: status{LastRunTime: now.UTC().Format(time.RFC3339), Vetters: []vetterStatus{}},
+	}
+}
+
+func (c *content) add(n *apiv1.Note) error {
+	b, err := report.MarshalNote(n)
+	if err != nil {
+		return err
+	}
+	c.Notes = append(c.Notes, b)
+	switch n.GetLevel() {
+	case apiv1.NoteLevel_INFO:
+		c.Status.Info++
+	case apiv1.NoteLevel_WARNING:
+		c.Status.Warning++
+	case apiv1.NoteLevel_ERROR:
+		c.Status.Error++
+	}
+	c.Status.Vetters[len(c.Status.Vetters)-1].Notes++
+	return nil
+}
+
+// build splits r into the content of the cluster report and of the reports
+// of every namespace with notes or listed in namespaces.
+func build(r *report.Report, now time.Time, namespaces []string) (*content, map[string]*content, error) {
+	cluster := newContent(now)
+	nsContent := map[string]*content{}
+	for _, ns := range namespaces {
+		nsContent[ns] = newContent(now)
+	}
+	for _, res := range r.Results {
+		for _, n := range res.Notes {
+			if ns := n.GetAttr()["namespace"]; ns != "" && nsContent[ns] == nil {
+				nsContent[ns] = newContent(now)
+			}
+		}
+	}
+
+	for _, res := range r.Results {
+		vs := vetterStatus{ID: res.Info.GetId(), Version: res.Info.GetVersion()}
+		if res.Err != nil {
+			vs.Error = res.Err.Error()
+		}
+		// Every report lists every vetter, so it shows which ran. Suppressed
+		// notes are only counted for the whole cluster.
+		for _, c := range nsContent {
+			c.Status.Vetters = append(c.Status.Vetters, vs)
+		}
+		vs.Suppressed = res.Suppressed
+		cluster.Status.Vetters = append(cluster.Status.Vetters, vs)
+		for _, n := range res.Notes {
+			if err := cluster.add(n); err != nil {
+				return nil, nil, err
+			}
+			if ns := n.GetAttr()["namespace"]; ns != "" {
+				if err := nsContent[ns].add(n); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return cluster, nsContent, nil
+}
+
+// Write stores r in the report resources. Namespace reports written by an
+// earlier run for namespaces without notes anymore are emptied.
+func (w *Writer) Write(ctx context.Context, r *report.Report) error {
+	existing, err := w.client.Resource(NamespaceVetReports).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "metadata.name=" + w.name,
+	})
+	if err != nil {
+		return err
+	}
+	var namespaces []string
+	for _, u := range existing.Items {
+		if u.GetName() == w.name {
+			namespaces = append(namespaces, u.GetNamespace())
+		}
+	}
+
+	cluster, nsContent, err := build(r, time.Now(), namespaces)
+	if err != nil {
+		return err
+	}
+	if err := w.apply(ctx, w.client.Resource(VetReports), "VetReport", "", cluster); err != nil {
+		return err
+	}
+	for ns, c := range nsContent {
+		res := w.client.Resource(NamespaceVetReports).Namespace(ns)
+		if err := w.apply(ctx, res, "NamespaceVetReport", ns, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply creates or replaces the named report with c.
+func (w *Writer) apply(ctx context.Context, res dynamic.ResourceInterface, kind, namespace string, c *content) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	// Unlike encoding/json, decodes integers as int64 like the API server.
+	if err := utiljson.Unmarshal(b, &obj.Object); err != nil {
+		return err
+	}
+	obj.SetAPIVersion(Group + "/" + Version)
+	obj.SetKind(kind)
+	obj.SetName(w.name)
+	obj.SetNamespace(namespace)
+
+	cur, err := res.Get(ctx, w.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = res.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(cur.GetResourceVersion())
+	obj.SetLabels(cur.GetLabels())
+	obj.SetAnnotations(cur.GetAnnotations())
+	_, err = res.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,13 @@
+package vetreport
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVetreport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Vetreport Suite")
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetreport
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+func note(id string, level apiv1.NoteLevel, namespace string) *apiv1.Note {
+	n := &apiv1.Note{Id: id, Type: "test", Level: level}
+	if namespace != "" {
+		n.Attr = map[string]string{"namespace": namespace}
+	}
+	return n
+}
+
+func get(client *dynamicfake.FakeDynamicClient, gvr schema.GroupVersionResource, namespace string) *unstructured.Unstructured {
+	u, err := client.Resource(gvr).Namespace(namespace).Get(context.Background(), "istio-vet", metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	return u
+}
+
+func summary(u *unstructured.Unstructured) (info, warning, errs int64) {
+	info, _, _ = unstructured.NestedInt64(u.Object, "status", "info")
+	warning, _, _ = unstructured.NestedInt64(u.Object, "status", "warning")
+	errs, _, _ = unstructured.NestedInt64(u.Object, "status", "error")
+	return
+}
+
+func noteIDs(u *unstructured.Unstructured) []string {
+	notes, _, err := unstructured.NestedSlice(u.Object, "notes")
+	Expect(err).NotTo(HaveOccurred())
+	ids := []string{}
+	for _, n := range notes {
+		ids = append(ids, n.(map[string]interface{})["id"].(string))
+	}
+	return ids
+}
+
+var _ = Describe("Writer", func() {
+	var (
+		client *dynamicfake.FakeDynamicClient
+		w      *Writer
+	)
+
+	BeforeEach(func() {
+		client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{
+				VetReports:          "VetReportList",
+				NamespaceVetReports: "NamespaceVetReportList",
+			})
+		w = NewWriter(client, "istio-vet")
+	})
+
+	It("writes cluster and namespace reports", func() {
+		r := &report.Report{Results: []*report.VetterResult{
+			{
+				Info: &apiv1.Info{Id: "a", Version: "0.1.0"},
+				Notes: []*apiv1.Note{
+					note("1", apiv1.NoteLevel_WARNING, "foo"),
+					note("2", apiv1.NoteLevel_ERROR, "bar"),
+					note("3", apiv1.NoteLevel_INFO, ""),
+				},
+				Suppressed: 2,
+			},
+			{Info: &apiv1.Info{Id: "b", Version: "0.1.0"}, Err: errors.New("boom")},
+		}}
+		Expect(w.Write(context.Background(), r)).To(Succeed())
+
+		u := get(client, VetReports, "")
+		Expect(u.GetKind()).To(Equal("VetReport"))
+		Expect(noteIDs(u)).To(Equal([]string{"1", "2", "3"}))
+		info, warning, errs := summary(u)
+		Expect([]int64{info, warning, errs}).To(Equal([]int64{1, 1, 1}))
+		vetters, _, _ := unstructured.NestedSlice(u.Object, "status", "vetters")
+		Expect(vetters).To(HaveLen(2))
+		Expect(vetters[0]).To(HaveKeyWithValue("notes", BeEquivalentTo(3)))
+		Expect(vetters[0]).To(HaveKeyWithValue("suppressed", BeEquivalentTo(2)))
+		Expect(vetters[1]).To(HaveKeyWithValue("error", "boom"))
+
+		u = get(client, NamespaceVetReports, "foo")
+		Expect(u.GetKind()).To(Equal("NamespaceVetReport"))
+		Expect(noteIDs(u)).To(Equal([]string{"1"}))
+		info, warning, errs = summary(u)
+		Expect([]int64{info, warning, errs}).To(Equal([]int64{0, 1, 0}))
+		vetters, _, _ = unstructured.NestedSlice(u.Object, "status", "vetters")
+		Expect(vetters).To(HaveLen(2))
+		Expect(vetters[0]).To(HaveKeyWithValue("notes", BeEquivalentTo(1)))
+		Expect(vetters[0]).NotTo(HaveKey("suppressed"))
+
+		Expect(noteIDs(get(client, NamespaceVetReports, "bar"))).To(Equal([]string{"2"}))
+	})
+
+	It("updates reports and empties those of namespaces without notes", func() {
+		r := &report.Report{Results: []*report.VetterResult{{
+			Info:  &apiv1.Info{Id: "a"},
+			Notes: []*apiv1.Note{note("1", apiv1.NoteLevel_WARNING, "foo")},
+		}}}
+		Expect(w.Write(context.Background(), r)).To(Succeed())
+
+		r.Results[0].Notes = []*apiv1.Note{note("2", apiv1.NoteLevel_WARNING, "bar")}
+		Expect(w.Write(context.Background(), r)).To(Succeed())
+
+		Expect(noteIDs(get(client, VetReports, ""))).To(Equal([]string{"2"}))
+		Expect(noteIDs(get(client, NamespaceVetReports, "bar"))).To(Equal([]string{"2"}))
+		u := get(client, NamespaceVetReports, "foo")
+		Expect(noteIDs(u)).To(BeEmpty())
+		_, warning, _ := summary(u)
+		Expect(warning).To(BeZero())
+	})
+})
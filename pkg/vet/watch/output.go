@@ -0,0 +1,129 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+)
+
+type vetterUpdateOutput struct {
+	ID       string            `json:"id"`
+	Version  string            `json:"version"`
+	New      []json.RawMessage `json:"new"`
+	Resolved []json.RawMessage `json:"resolved"`
+	Changed  []json.RawMessage `json:"changed"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type updateOutput struct {
+	Time    string               `json:"time"`
+	Vetters []vetterUpdateOutput `json:"vetters"`
+}
+
+func marshalNotes(notes []*apiv1.Note) ([]json.RawMessage, error) {
+	out := []json.RawMessage{}
+	for _, n := range notes {
+		b, err := report.MarshalNote(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func marshalUpdate(u *Update) ([]byte, error) {
+	out := updateOutput{Time: u.Time.UTC().Format(time.RFC3339), Vetters: []vetterUpdateOutput{}}
+	for _, vu := range u.Vetters {
+		vo := vetterUpdateOutput{ID: vu.Info.GetId(), Version: vu.Info.GetVersion()}
+		var err error
+		if vo.New, err = marshalNotes(vu.New); err != nil {
+			return nil, err
+		}
+		if vo.Resolved, err = marshalNotes(vu.Resolved); err != nil {
+			return nil, err
+		}
+		if vo.Changed, err = marshalNotes(vu.Changed); err != nil {
+			return nil, err
+		}
+		if vu.Err != nil {
+			vo.Error = vu.Err.Error()
+		}
+		out.Vetters = append(out.Vetters, vo)
+	}
+	return json.Marshal(out)
+}
+
+// WriteUpdate renders an update in one of the report formats. JSON updates
+// are written one per line and YAML updates as separate documents, so a
+// stream of updates can be consumed incrementally.
+func WriteUpdate(w io.Writer, u *Update, format string) error {
+	switch format {
+	case report.FormatText:
+		return writeUpdateText(w, u)
+	case report.FormatJSON:
+		b, err := marshalUpdate(u)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case report.FormatYAML:
+		b, err := marshalUpdate(u)
+		if err != nil {
+			return err
+		}
+		y, err := yaml.JSONToYAML(b)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "---\n%s", y)
+		return err
+	}
+	return report.CheckFormat(format)
+}
+
+func writeUpdateText(w io.Writer, u *Update) error {
+	ts := u.Time.Format(time.RFC3339)
+	for _, vu := range u.Vetters {
+		id := vu.Info.GetId()
+		if vu.Err != nil {
+			fmt.Fprintf(w, "[%s] Vetter: \"%s\" reported error: %s\n\n", ts, id, vu.Err)
+		}
+		for _, n := range vu.New {
+			fmt.Fprintf(w, "[%s] New note from vetter \"%s\":\n", ts, id)
+			report.WriteNote(w, n)
+		}
+		for _, n := range vu.Resolved {
+			fmt.Fprintf(w, "[%s] Resolved note from vetter \"%s\":\n", ts, id)
+			report.WriteNote(w, n)
+		}
+		for _, n := range vu.Changed {
+			fmt.Fprintf(w, "[%s] Changed note from vetter \"%s\":\n", ts, id)
+			report.WriteNote(w, n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package watch continuously re-runs vetters as the resources they list
+// change and reports the notes which appeared or were resolved.
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+// maxDelayFactor bounds how long a stream of changes can postpone a run, as
+// a multiple of the debounce period.
+const maxDelayFactor = 10
+
+// subscriberBuffer is the number of updates buffered for a subscriber.
+const subscriberBuffer = 16
+
+// Target is a vetter along with the resources it lists. The vetter is re-run
+// whenever one of these resources is added, updated or deleted.
+type Target struct {
+	Vetter    vetter.Vetter
+	Resources []string
+}
+
+// VetterUpdate holds the notes of a vetter which appeared or were resolved
+// since its previous run.
+type VetterUpdate struct {
+	Info     *apiv1.Info
+	New      []*apiv1.Note
+	Resolved []*apiv1.Note
+	Changed  []*apiv1.Note
+	Err      error
+}
+
+// Update is emitted after vetters are run if any of them generated new
+// notes, resolved notes or failed.
+type Update struct {
+	Time    time.Time
+	Vetters []*VetterUpdate
+}
+
+// Watcher re-runs vetters on changes to the resources they list.
+type Watcher struct {
+	targets  []Target
+	debounce time.Duration
+	opts     vetter.RunOptions
+
+	kick chan struct{}
+
+	mu      sync.Mutex
+	dirty   map[string]bool
+	results map[string]*report.VetterResult
+	ready   bool
+
+	subscribers    map[int]chan *Update
+	nextSubscriber int
+}
+
+// New returns a Watcher for targets. It registers event handlers on the
+// informers of factory, so it must be called before the factory is started.
+// Changes are batched until no change happened for the debounce period.
+// Vetters are run as configured by opts.
+func New(factory vetter.ResourceListGetter, targets []Target, debounce time.Duration,
+	opts vetter.RunOptions) (*Watcher, error) {
+	w := &Watcher{
+		targets:  targets,
+		debounce: debounce,
+		opts:     opts,
+		kick:     make(chan struct{}, 1),
+		dirty:    map[string]bool{},
+		results:  map[string]*report.VetterResult{},
+
+		subscribers: map[int]chan *Update{},
+	}
+	registered := map[string]bool{}
+	for _, t := range targets {
+		for _, r := range t.Resources {
+			if registered[r] {
+				continue
+			}
+			inf, err := vetter.Informer(factory, r)
+			if err != nil {
+				return nil, err
+			}
+			inf.AddEventHandler(w.handler(r))
+			registered[r] = true
+		}
+	}
+	return w, nil
+}
+
+func (w *Watcher) handler(resource string) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.changed(resource)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Periodic resyncs deliver updates without changes.
+			o, oErr := meta.Accessor(oldObj)
+			n, nErr := meta.Accessor(newObj)
+			if oErr == nil && nErr == nil && o.GetResourceVersion() == n.GetResourceVersion() {
+				return
+			}
+			w.changed(resource)
+		},
+		DeleteFunc: func(obj interface{}) {
+			w.changed(resource)
+		},
+	}
+}
+
+func (w *Watcher) changed(resource string) {
+	w.mu.Lock()
+	w.dirty[resource] = true
+	w.mu.Unlock()
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+// takeDirty returns the targets affected by the resources changed since
+// the last call.
+func (w *Watcher) takeDirty() []Target {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var targets []Target
+	for _, t := range w.targets {
+		for _, r := range t.Resources {
+			if w.dirty[r] {
+				targets = append(targets, t)
+				break
+			}
+		}
+	}
+	w.dirty = map[string]bool{}
+	return targets
+}
+
+// Run runs all vetters, then re-runs the vetters affected by changes until
+// stopCh is closed. The informer caches must be synced before calling Run.
+// onUpdate is called from the Run goroutine; the first update holds all
+// notes generated by the initial run and is emitted even if there are none.
+func (w *Watcher) Run(stopCh <-chan struct{}, onUpdate func(*Update)) {
+	// Cancel running vetters once stopped.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Changes seen while the caches synced are covered by the initial run.
+	w.takeDirty()
+	w.run(ctx, w.targets, true, onUpdate)
+	w.mu.Lock()
+	w.ready = true
+	w.mu.Unlock()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-w.kick:
+		}
+		if !w.wait(stopCh) {
+			return
+		}
+		if targets := w.takeDirty(); len(targets) > 0 {
+			w.run(ctx, targets, false, onUpdate)
+		}
+	}
+}
+
+// wait returns once no change was seen for the debounce period, or false if
+// stopCh was closed.
+func (w *Watcher) wait(stopCh <-chan struct{}) bool {
+	quiet := time.NewTimer(w.debounce)
+	defer quiet.Stop()
+	deadline := time.NewTimer(maxDelayFactor * w.debounce)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return false
+		case <-w.kick:
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+			quiet.Reset(w.debounce)
+		case <-quiet.C:
+			return true
+		case <-deadline.C:
+			return true
+		}
+	}
+}
+
+// run runs targets and emits the update to onUpdate if anything changed,
+// or always if initial is set.
+func (w *Watcher) run(ctx context.Context, targets []Target, initial bool, onUpdate func(*Update)) {
+	u := &Update{Time: time.Now()}
+	vList := make([]vetter.Vetter, len(targets))
+	for i, t := range targets {
+		glog.V(2).Infof("Running vetter %s", t.Vetter.Info().GetId())
+		vList[i] = t.Vetter
+	}
+	runResults := vetter.RunAll(ctx, vList, w.opts)
+	if ctx.Err() != nil {
+		// Stopped, the vetters were cancelled.
+		return
+	}
+	results := make([]*report.VetterResult, len(targets))
+	for i, t := range targets {
+		info := t.Vetter.Info()
+		notes, err := runResults[i].Notes, runResults[i].Err
+		res := &report.VetterResult{Info: info, Notes: notes, Err: err, Suppressed: vetter.Suppressed(t.Vetter)}
+		results[i] = res
+
+		// Only the Run goroutine writes results, no need to lock for reading.
+		prev := w.results[info.GetId()]
+		if err != nil && prev != nil {
+			// Keep the last known notes, they are not resolved by a failure.
+			res.Notes = prev.Notes
+		}
+
+		vu := &VetterUpdate{Info: info, Err: err}
+		if err == nil {
+			var prevNotes []*apiv1.Note
+			if prev != nil {
+				prevNotes = prev.Notes
+			}
+			vu.New, vu.Resolved, vu.Changed = diff(prevNotes, notes)
+		}
+		if vu.Err != nil || len(vu.New) > 0 || len(vu.Resolved) > 0 || len(vu.Changed) > 0 {
+			u.Vetters = append(u.Vetters, vu)
+		}
+	}
+
+	// Publish the results and the update atomically, so subscribers see
+	// every change exactly once.
+	w.mu.Lock()
+	for _, res := range results {
+		w.results[res.Info.GetId()] = res
+	}
+	if len(u.Vetters) > 0 {
+		for id, ch := range w.subscribers {
+			select {
+			case ch <- u:
+			default:
+				glog.Warningf("Dropping watch subscriber %d which is not keeping up", id)
+				close(ch)
+				delete(w.subscribers, id)
+			}
+		}
+	}
+	w.mu.Unlock()
+
+	if (initial || len(u.Vetters) > 0) && onUpdate != nil {
+		onUpdate(u)
+	}
+}
+
+// Subscribe returns the latest result of every vetter which has run along
+// with a channel receiving every later update. Subscribers which don't keep
+// up are dropped by closing their channel. cancel must be called once the
+// subscriber is done.
+func (w *Watcher) Subscribe() (r *report.Report, updates <-chan *Update, cancel func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextSubscriber
+	w.nextSubscriber++
+	ch := make(chan *Update, subscriberBuffer)
+	w.subscribers[id] = ch
+	cancel = func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.subscribers[id]; ok {
+			close(ch)
+			delete(w.subscribers, id)
+		}
+	}
+	return w.report(), ch, cancel
+}
+
+// Ready returns true once all vetters have run at least once.
+func (w *Watcher) Ready() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ready
+}
+
+// Vetters returns information about all watched vetters.
+func (w *Watcher) Vetters() []*apiv1.Info {
+	infos := make([]*apiv1.Info, len(w.targets))
+	for i, t := range w.targets {
+		infos[i] = t.Vetter.Info()
+	}
+	return infos
+}
+
+// Report returns the latest result of every vetter which has run.
+func (w *Watcher) Report() *report.Report {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.report()
+}
+
+func (w *Watcher) report() *report.Report {
+	r := &report.Report{}
+	for _, t := range w.targets {
+		if res, ok := w.results[t.Vetter.Info().GetId()]; ok {
+			r.Results = append(r.Results, res)
+		}
+	}
+	return r
+}
+
+// diff returns the notes in cur but not in prev, the notes in prev but not
+// in cur, comparing note IDs, and the notes in both whose fingerprint changed.
+func diff(prev, cur []*apiv1.Note) (added, resolved, changed []*apiv1.Note) {
+	prevFingerprints := map[string]string{}
+	for _, n := range prev {
+		prevFingerprints[n.GetId()] = n.GetFingerprint()
+	}
+	curIDs := map[string]bool{}
+	for _, n := range cur {
+		curIDs[n.GetId()] = true
+		fp, ok := prevFingerprints[n.GetId()]
+		if !ok {
+			added = append(added, n)
+		} else if fp != n.GetFingerprint() {
+			changed = append(changed, n)
+		}
+	}
+	for _, n := range prev {
+		if !curIDs[n.GetId()] {
+			resolved = append(resolved, n)
+		}
+	}
+	return added, resolved, changed
+}
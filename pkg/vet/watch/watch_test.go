@@ -0,0 +1,223 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	v1 "k8s.io/client-go/listers/core/v1"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vet/report"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+type factory struct {
+	k8s   informers.SharedInformerFactory
+	istio istioinformer.SharedInformerFactory
+}
+
+func (f *factory) K8s() informers.SharedInformerFactory       { return f.k8s }
+func (f *factory) Istio() istioinformer.SharedInformerFactory { return f.istio }
+
+// podVetter generates a note for every pod.
+type podVetter struct {
+	podLister v1.PodLister
+	runs      int
+}
+
+func (v *podVetter) Vet() ([]*apiv1.Note, error) {
+	v.runs++
+	pods, err := v.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	notes := []*apiv1.Note{}
+	for _, p := range pods {
+		notes = append(notes, &apiv1.Note{
+			Id:      p.Name,
+			Type:    "pod",
+			Summary: "Pod ${pod_name}",
+			Level:   apiv1.NoteLevel_INFO,
+			Attr:    map[string]string{"pod_name": p.Name},
+		})
+	}
+	return notes, nil
+}
+
+func (v *podVetter) Info() *apiv1.Info {
+	return &apiv1.Info{Id: "pods", Version: "0.1.0"}
+}
+
+// staticVetter generates no notes and only depends on services.
+type staticVetter struct {
+	runs int
+}
+
+func (v *staticVetter) Vet() ([]*apiv1.Note, error) {
+	v.runs++
+	return nil, nil
+}
+
+func (v *staticVetter) Info() *apiv1.Info {
+	return &apiv1.Info{Id: "static", Version: "0.1.0"}
+}
+
+func pod(name string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+}
+
+var _ = Describe("Watcher", func() {
+	var (
+		client  *k8sfake.Clientset
+		pv      *podVetter
+		sv      *staticVetter
+		w       *Watcher
+		stopCh  chan struct{}
+		updates chan *Update
+	)
+
+	BeforeEach(func() {
+		client = k8sfake.NewSimpleClientset(pod("a"))
+		f := &factory{
+			k8s:   informers.NewSharedInformerFactory(client, 0),
+			istio: istioinformer.NewSharedInformerFactory(istiofake.NewSimpleClientset(), 0),
+		}
+		pv = &podVetter{podLister: f.K8s().Core().V1().Pods().Lister()}
+		sv = &staticVetter{}
+		var err error
+		w, err = New(f, []Target{
+			{Vetter: pv, Resources: []string{vetter.Pods}},
+			{Vetter: sv, Resources: []string{vetter.Services}},
+		}, 10*time.Millisecond, vetter.RunOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		stopCh = make(chan struct{})
+		f.k8s.Start(stopCh)
+		f.k8s.WaitForCacheSync(stopCh)
+
+		// A run in progress when the spec ends must not see the channel of
+		// the next spec.
+		ch := make(chan *Update, 10)
+		updates = ch
+		go w.Run(stopCh, func(u *Update) { ch <- u })
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+	})
+
+	It("reports new and resolved notes of affected vetters", func() {
+		var u *Update
+		Eventually(updates).Should(Receive(&u))
+		Eventually(w.Ready).Should(BeTrue())
+		Expect(w.Vetters()).To(HaveLen(2))
+		Expect(u.Vetters).To(HaveLen(1))
+		Expect(u.Vetters[0].Info.GetId()).To(Equal("pods"))
+		Expect(u.Vetters[0].New).To(HaveLen(1))
+		Expect(u.Vetters[0].New[0].GetId()).To(Equal("a"))
+
+		ctx := context.Background()
+		_, err := client.CoreV1().Pods("default").Create(ctx, pod("b"), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(updates).Should(Receive(&u))
+		Expect(u.Vetters[0].New).To(HaveLen(1))
+		Expect(u.Vetters[0].New[0].GetId()).To(Equal("b"))
+		Expect(u.Vetters[0].Resolved).To(BeEmpty())
+
+		Expect(client.CoreV1().Pods("default").Delete(ctx, "a", metav1.DeleteOptions{})).To(Succeed())
+		Eventually(updates).Should(Receive(&u))
+		Expect(u.Vetters[0].New).To(BeEmpty())
+		Expect(u.Vetters[0].Resolved).To(HaveLen(1))
+		Expect(u.Vetters[0].Resolved[0].GetId()).To(Equal("a"))
+
+		By("only re-running the vetters listing changed resources")
+		Expect(sv.runs).To(Equal(1))
+
+		r := w.Report()
+		Expect(r.Results).To(HaveLen(2))
+		Expect(r.Results[0].Notes).To(HaveLen(1))
+		Expect(r.Results[0].Notes[0].GetId()).To(Equal("b"))
+	})
+
+	It("sends later updates to subscribers", func() {
+		Eventually(w.Ready).Should(BeTrue())
+		r, sub, cancel := w.Subscribe()
+		Expect(r.Results).To(HaveLen(2))
+		Expect(r.Results[0].Notes).To(HaveLen(1))
+
+		ctx := context.Background()
+		_, err := client.CoreV1().Pods("default").Create(ctx, pod("b"), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		var u *Update
+		Eventually(sub).Should(Receive(&u))
+		Expect(u.Vetters[0].New).To(HaveLen(1))
+		Expect(u.Vetters[0].New[0].GetId()).To(Equal("b"))
+
+		cancel()
+		Expect(sub).To(BeClosed())
+		cancel()
+	})
+
+	It("writes updates in every format", func() {
+		var u *Update
+		Eventually(updates).Should(Receive(&u))
+		for _, format := range report.Formats {
+			var b bytes.Buffer
+			Expect(WriteUpdate(&b, u, format)).To(Succeed())
+			Expect(b.String()).To(ContainSubstring("Pod a"))
+		}
+		Expect(WriteUpdate(&bytes.Buffer{}, u, "xml")).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Unknown resources", func() {
+	It("are rejected", func() {
+		f := &factory{
+			k8s:   informers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0),
+			istio: istioinformer.NewSharedInformerFactory(istiofake.NewSimpleClientset(), 0),
+		}
+		_, err := New(f, []Target{{Vetter: &staticVetter{}, Resources: []string{"widgets"}}}, time.Second, vetter.RunOptions{})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("diff", func() {
+	note := func(id, fingerprint string) *apiv1.Note {
+		return &apiv1.Note{Id: id, Fingerprint: fingerprint}
+	}
+
+	It("compares notes by id and fingerprint", func() {
+		prev := []*apiv1.Note{note("a", "1"), note("b", "1"), note("c", "1")}
+		cur := []*apiv1.Note{note("a", "1"), note("b", "2"), note("d", "1")}
+		added, resolved, changed := diff(prev, cur)
+		Expect(added).To(Equal([]*apiv1.Note{cur[2]}))
+		Expect(resolved).To(Equal([]*apiv1.Note{prev[2]}))
+		Expect(changed).To(Equal([]*apiv1.Note{cur[1]}))
+	})
+})
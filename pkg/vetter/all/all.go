@@ -0,0 +1,33 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package all registers all vetters of this repository. Import it for its
+// side effects:
+//
+//	import _ "github.com/aspenmesh/istio-vet/pkg/vetter/all"
+package all
+
+import (
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/applabel"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/conflictingvirtualservicehost"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/danglingroutedestinationhost"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/meshversion"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/missingsidecar"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/podsinmesh"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/serviceassociation"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/serviceportprefix"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/workloadversion"
+)
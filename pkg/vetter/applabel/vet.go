@@ -19,25 +19,106 @@ limitations under the License.
 package applabel
 
 import (
+	_ "embed"
+	"fmt"
+	"strings"
+
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
 	"github.com/aspenmesh/istio-vet/pkg/vetter"
 	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	v1 "k8s.io/client-go/listers/core/v1"
 )
 
 const (
 	vetterID                = "AppLabel"
 	missingAppLabelNoteType = "missing-app-label"
-	missingAppLabelSummary  = "Missing app label - ${pod_name}"
+	missingAppLabelSummary  = "Missing ${label} label - ${pod_name}"
 	missingAppLabelMsg      = "The pod ${pod_name} in namespace ${namespace}" +
-		" is missing \"app\" label. Consider adding the label \"app\" to the pod."
+		" is missing \"${label}\" label. Consider adding the label \"${label}\" to the pod."
 )
 
+//go:embed README-missing-app-label.md
+var missingAppLabelDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        missingAppLabelNoteType,
+		Code:        "IV0301",
+		Vetter:      vetterID,
+		Remediation: "Add the missing label, e.g. a unique and meaningful \"app\" label, to the pod template of the workload.",
+		DocsURL:     vetter.DocsURL("applabel", missingAppLabelNoteType),
+		Doc:         missingAppLabelDoc,
+	},
+}
+
+// appLabelFix returns a Fix setting the "app" label of p, or of the pod
+// template of the workload managing it, to the name of the workload. It
+// returns nil if p is managed by anything else.
+func appLabelFix(p *corev1.Pod) *apiv1.Fix {
+	if w := util.Workload(p); w != nil {
+		return util.PodTemplateFix("Add label "+util.IstioAppLabel+"="+w.GetName()+" to "+w.GetKind()+" "+w.GetName(),
+			w, map[string]string{util.IstioAppLabel: w.GetName()}, nil)
+	}
+	if metav1.GetControllerOf(p) != nil {
+		return nil
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{util.IstioAppLabel: p.Name},
+		},
+	}
+	return util.NewFix("Add label "+util.IstioAppLabel+"="+p.Name+" to Pod "+p.Name,
+		util.ObjectRef(util.PodKind, p.Namespace, p.Name, p.UID),
+		apiv1.PatchType_STRATEGIC_MERGE_PATCH, patch)
+}
+
 // AppLabel implements Vetter interface
 type AppLabel struct {
-	info      apiv1.Info
-	nsLister  v1.NamespaceLister
-	podLister v1.PodLister
+	info           apiv1.Info
+	nsLister       v1.NamespaceLister
+	podLister      v1.PodLister
+	requiredLabels []string
+	level          apiv1.NoteLevel
+}
+
+// Config is the config of the vetter.
+type Config struct {
+	// RequiredLabels of the pods, defaults to ["app"]
+	RequiredLabels []string `json:"requiredLabels"`
+
+	// Level of the notes, defaults to "warning"
+	Level string `json:"level"`
+}
+
+// Configure implements the vetter.Configurable interface
+func (m *AppLabel) Configure(config map[string]interface{}) error {
+	var c Config
+	if err := vetter.DecodeConfig(config, &c); err != nil {
+		return err
+	}
+	if c.RequiredLabels != nil {
+		if len(c.RequiredLabels) == 0 {
+			return fmt.Errorf("requiredLabels must not be empty")
+		}
+		for _, l := range c.RequiredLabels {
+			if errs := validation.IsQualifiedName(l); len(errs) > 0 {
+				return fmt.Errorf("invalid label %q: %s", l, strings.Join(errs, ", "))
+			}
+		}
+		m.requiredLabels = c.RequiredLabels
+	}
+	if c.Level != "" {
+		l, err := vetter.ParseLevel(c.Level)
+		if err != nil {
+			return err
+		}
+		m.level = l
+	}
+	return nil
 }
 
 // Vet returns the list of generated notes
@@ -54,22 +135,35 @@ func (m *AppLabel) Vet() ([]*apiv1.Note, error) {
 		return nil, err
 	}
 	for _, p := range pods {
-		if _, ok := p.Labels[util.IstioAppLabel]; !ok {
-			notes = append(notes, &apiv1.Note{
+		for _, label := range m.requiredLabels {
+			if _, ok := p.Labels[label]; ok {
+				continue
+			}
+			n := &apiv1.Note{
 				Type:    missingAppLabelNoteType,
 				Summary: missingAppLabelSummary,
 				Msg:     missingAppLabelMsg,
-				Level:   apiv1.NoteLevel_WARNING,
+				Level:   m.level,
 				Attr: map[string]string{
 					"pod_name":  p.Name,
-					"namespace": p.Namespace}})
+					"namespace": p.Namespace,
+					"label":     label},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.PodKind, p.Namespace, p.Name, p.UID)}}
+			// Only the value of the app label can be derived from the pod.
+			if label == util.IstioAppLabel {
+				n.Fix = appLabelFix(p)
+			}
+			notes = append(notes, n)
 		}
 	}
 
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i], notes[i].Attr["label"])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
@@ -77,17 +171,34 @@ func (m *AppLabel) Info() *apiv1.Info {
 	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
 }
 
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Inspects the labels of the pods in the mesh and generates notes if the label \"app\" or other required labels are missing.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Pods},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
 // NewVetter returns "AppLabel" which implements Vetter Interface
 func NewVetter(factory vetter.ResourceListGetter) *AppLabel {
 	return &AppLabel{
-		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
-		podLister: factory.K8s().Core().V1().Pods().Lister(),
+		nsLister:       factory.K8s().Core().V1().Namespaces().Lister(),
+		podLister:      factory.K8s().Core().V1().Pods().Lister(),
+		requiredLabels: []string{util.IstioAppLabel},
+		level:          apiv1.NoteLevel_WARNING,
 	}
 }
 
 func NewVetterFromListers(nsLister v1.NamespaceLister, podLister v1.PodLister) *AppLabel {
 	return &AppLabel{
-		nsLister:  nsLister,
-		podLister: podLister,
+		nsLister:       nsLister,
+		podLister:      podLister,
+		requiredLabels: []string{util.IstioAppLabel},
+		level:          apiv1.NoteLevel_WARNING,
 	}
 }
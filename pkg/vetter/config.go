@@ -0,0 +1,101 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// Configurable is implemented by vetters accepting configuration from their
+// section of the config file, e.g.
+//  vetters:
+//    applabel:
+//      requiredLabels: [app, version]
+type Configurable interface {
+	// Configure decodes and validates the config section of the vetter,
+	// typically with DecodeConfig. It is called before the vetter runs.
+	Configure(config map[string]interface{}) error
+}
+
+// DecodeConfig decodes a config section into out, a pointer to the config
+// struct of a vetter with json tags. Keys are matched case insensitively, as
+// the config file keys are lower cased, and unknown keys are errors.
+func DecodeConfig(config map[string]interface{}, out interface{}) error {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	return d.Decode(out)
+}
+
+// ParseLevel parses a note level like "warning", case insensitively.
+func ParseLevel(s string) (apiv1.NoteLevel, error) {
+	l, ok := apiv1.NoteLevel_value[strings.ToUpper(s)]
+	if !ok || l == int32(apiv1.NoteLevel_UNUSED) {
+		return apiv1.NoteLevel_UNUSED, fmt.Errorf("invalid level %q, must be one of: info, warning, error", s)
+	}
+	return apiv1.NoteLevel(l), nil
+}
+
+// configure passes the sections of config to the vetters they are keyed by,
+// matching vetter ids case insensitively.
+func configure(vList []Vetter, config map[string]interface{}) error {
+	sections := map[string]map[string]interface{}{}
+	for id, c := range config {
+		if !registered(id) {
+			return fmt.Errorf("config for unknown vetter %q", id)
+		}
+		section, ok := c.(map[string]interface{})
+		if !ok && c != nil {
+			return fmt.Errorf("config for vetter %q must be a map", id)
+		}
+		sections[strings.ToLower(id)] = section
+	}
+	for _, v := range vList {
+		id := v.Info().GetId()
+		section, ok := sections[strings.ToLower(id)]
+		if !ok {
+			continue
+		}
+		c, ok := v.(Configurable)
+		if !ok {
+			return fmt.Errorf("vetter %s doesn't accept config", id)
+		}
+		if err := c.Configure(section); err != nil {
+			return fmt.Errorf("invalid config for vetter %s: %s", id, err)
+		}
+	}
+	return nil
+}
+
+// registered returns true if a vetter with the given id, matched case
+// insensitively, is registered.
+func registered(id string) bool {
+	for _, r := range Registrations() {
+		if strings.EqualFold(r.ID, id) {
+			return true
+		}
+	}
+	return false
+}
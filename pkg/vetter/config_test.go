@@ -0,0 +1,97 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+type testConfig struct {
+	RequiredLabels []string `json:"requiredLabels"`
+}
+
+var _ = Describe("DecodeConfig", func() {
+	It("matches lower cased keys", func() {
+		var c testConfig
+		Expect(vetter.DecodeConfig(map[string]interface{}{
+			"requiredlabels": []interface{}{"app", "version"},
+		}, &c)).To(Succeed())
+		Expect(c.RequiredLabels).To(Equal([]string{"app", "version"}))
+	})
+
+	It("rejects unknown keys and invalid values", func() {
+		var c testConfig
+		Expect(vetter.DecodeConfig(map[string]interface{}{"labels": []interface{}{"app"}}, &c)).NotTo(Succeed())
+		Expect(vetter.DecodeConfig(map[string]interface{}{"requiredlabels": "app"}, &c)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("ParseLevel", func() {
+	It("parses note levels", func() {
+		Expect(vetter.ParseLevel("Warning")).To(Equal(apiv1.NoteLevel_WARNING))
+		_, err := vetter.ParseLevel("unused")
+		Expect(err).To(HaveOccurred())
+		_, err = vetter.ParseLevel("fatal")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Configuring vetters", func() {
+	var s *vetter.Selector
+
+	BeforeEach(func() {
+		var err error
+		s, err = vetter.NewSelector(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("passes the sections to the vetters", func() {
+		_, err := s.NewVetters(newFactory(), map[string]interface{}{
+			"applabel": map[string]interface{}{
+				"requiredlabels": []interface{}{"app", "version"},
+				"level":          "error",
+			},
+			"serviceportprefix": map[string]interface{}{
+				"protocols": []interface{}{"http", "grpc"},
+			},
+			"podsinmesh": map[string]interface{}{
+				"systemnamespaces": []interface{}{"kube-system"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("surfaces invalid config", func() {
+		for _, config := range []map[string]interface{}{
+			{"applabel": map[string]interface{}{"requiredlabels": []interface{}{"not a label"}}},
+			{"applabel": map[string]interface{}{"level": "fatal"}},
+			{"applabel": map[string]interface{}{"unknown": true}},
+			{"serviceportprefix": map[string]interface{}{"protocols": []interface{}{}}},
+			{"applabel": "app"},
+			{"unknown": map[string]interface{}{}},
+			// Doesn't accept config
+			{"meshversion": map[string]interface{}{}},
+		} {
+			_, err := s.NewVetters(newFactory(), config)
+			Expect(err).To(HaveOccurred(), "config %v", config)
+		}
+	})
+})
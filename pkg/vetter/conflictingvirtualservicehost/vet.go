@@ -17,13 +17,17 @@ limitations under the License.
 package conflictingvirtualservicehost
 
 import (
+	"context"
+	_ "embed"
 	"fmt"
 	"regexp"
 	"strings"
 
+	"github.com/golang/glog"
 	istioNet "istio.io/api/networking/v1beta1"
 	istioClientNet "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istioNetListers "istio.io/client-go/pkg/listers/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
 	v1 "k8s.io/client-go/listers/core/v1"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
@@ -42,6 +46,21 @@ const (
 		"update the rules so they do not conflict."
 )
 
+//go:embed README-host-in-multiple-vs.md
+var hostInMultipleVsDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        vsHostNoteType,
+		Code:        "IV0701",
+		Vetter:      vetterID,
+		Remediation: "Merge the conflicting routes into a single VirtualService, or change the matched URIs so they do not overlap.",
+		DocsURL:     vetter.DocsURL("conflictingvirtualservicehost", vsHostNoteType),
+		Doc:         hostInMultipleVsDoc,
+	},
+}
+
 type routeRuleType int
 
 const (
@@ -54,6 +73,7 @@ const (
 type VsHost struct {
 	nsLister v1.NamespaceLister
 	vsLister istioNetListers.VirtualServiceLister
+	env      util.Environment
 }
 
 type routeRule struct {
@@ -61,6 +81,7 @@ type routeRule struct {
 	route     string
 	vsName    string
 	namespace string
+	uid       types.UID
 	priority  int
 }
 
@@ -83,12 +104,17 @@ func asString(rrType routeRuleType) string {
 // CreateVirtualServiceNotes checks for multiple vs defining the same host and
 // generates notes for these cases
 func CreateVirtualServiceNotes(virtualServices []*istioClientNet.VirtualService) ([]*apiv1.Note, error) {
+	return createVirtualServiceNotes(context.Background(), util.Environment{}, virtualServices)
+}
+
+func createVirtualServiceNotes(ctx context.Context, env util.Environment,
+	virtualServices []*istioClientNet.VirtualService) ([]*apiv1.Note, error) {
 	vsByHost := map[string][]*istioClientNet.VirtualService{}
 	for _, vs := range virtualServices {
 		for _, host := range vs.Spec.GetHosts() {
-			h, err := util.ConvertHostnameToFQDN(host, vs.Namespace)
+			h, err := env.ConvertHostnameToFQDN(host, vs.Namespace)
 			if err != nil {
-				fmt.Printf("Unable to convert hostname: %s\n", err.Error())
+				glog.Errorf("Unable to convert hostname: %s", err)
 				return nil, err
 			}
 			if _, ok := vsByHost[h]; !ok {
@@ -100,20 +126,26 @@ func CreateVirtualServiceNotes(virtualServices []*istioClientNet.VirtualService)
 	}
 
 	// create vet notes
-	notes, err := addConflictingRulesNotes(vsByHost)
+	notes, err := addConflictingRulesNotes(ctx, vsByHost)
 
 	if err != nil {
 		return []*apiv1.Note{}, err
 	}
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i],
+			notes[i].Attr["host"], notes[i].Attr["routes"])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 	return notes, nil
 }
 
-func addConflictingRulesNotes(vsByHost map[string][]*istioClientNet.VirtualService) ([]*apiv1.Note, error) {
+func addConflictingRulesNotes(ctx context.Context, vsByHost map[string][]*istioClientNet.VirtualService) ([]*apiv1.Note, error) {
 	notes := []*apiv1.Note{}
 	for host, vsList := range vsByHost {
+		// Comparing the routes of many VirtualServices can take a while.
+		if err := ctx.Err(); err != nil {
+			return notes, err
+		}
 		if len(vsList) >= 1 {
 
 			conflictingRules, err := conflictingVirtualServices(vsList)
@@ -123,6 +155,11 @@ func addConflictingRulesNotes(vsByHost map[string][]*istioClientNet.VirtualServi
 			for _, conflict := range conflictingRules {
 				vs1 := conflict[0]
 				vs2 := conflict[1]
+				// The pair comes in lister order, sort it so the note and
+				// its fingerprint are the same across runs.
+				if ruleLess(vs2, vs1) {
+					vs1, vs2 = vs2, vs1
+				}
 				vsNames := []string{vs1.vsName + "." + vs1.namespace, vs2.vsName + "." + vs2.namespace}
 				conflictingRoutes := []string{vs1.route + " " + asString(vs1.ruleType),
 					vs2.route + " " + asString(vs2.ruleType)}
@@ -136,6 +173,10 @@ func addConflictingRulesNotes(vsByHost map[string][]*istioClientNet.VirtualServi
 						"host":     host,
 						"routes":   strings.Join(conflictingRoutes, " "),
 					},
+					Refs: []*apiv1.ObjectReference{
+						util.ObjectRef(util.VirtualServiceKind, vs1.namespace, vs1.vsName, vs1.uid),
+						util.ObjectRef(util.VirtualServiceKind, vs2.namespace, vs2.vsName, vs2.uid),
+					},
 				}
 				notes = append(notes, note)
 			}
@@ -145,6 +186,15 @@ func addConflictingRulesNotes(vsByHost map[string][]*istioClientNet.VirtualServi
 	return notes, nil
 }
 
+// ruleLess orders route rules by the name and namespace of their virtual
+// service, then by route.
+func ruleLess(a, b routeRule) bool {
+	if a.vsName+"."+a.namespace != b.vsName+"."+b.namespace {
+		return a.vsName+"."+a.namespace < b.vsName+"."+b.namespace
+	}
+	return a.route+" "+asString(a.ruleType) < b.route+" "+asString(b.ruleType)
+}
+
 // Return a list of pairs of virtual services that conflict.
 func conflictingVirtualServices(vsList []*istioClientNet.VirtualService) ([][]routeRule, error) {
 	trie := buildMergedVirtualServiceTrie(vsList)
@@ -374,27 +424,34 @@ func conflict(ancestorRule routeRule, descendantRule routeRule) (bool, error) {
 
 func getRouteRuleFromMatch(match *istioNet.StringMatch, vs *istioClientNet.VirtualService, prio int) routeRule {
 	if route := match.GetExact(); route != "" {
-		return routeRule{ruleType: exact, route: route, vsName: vs.Name, namespace: vs.Namespace, priority: prio}
+		return routeRule{ruleType: exact, route: route, vsName: vs.Name, namespace: vs.Namespace, uid: vs.UID, priority: prio}
 	} else if route := match.GetPrefix(); route != "" {
-		return routeRule{ruleType: prefix, route: route, vsName: vs.Name, namespace: vs.Namespace, priority: prio}
+		return routeRule{ruleType: prefix, route: route, vsName: vs.Name, namespace: vs.Namespace, uid: vs.UID, priority: prio}
 	} else if route := match.GetRegex(); route != "" {
-		return routeRule{ruleType: regex, route: route, vsName: vs.Name, namespace: vs.Namespace, priority: prio}
+		return routeRule{ruleType: regex, route: route, vsName: vs.Name, namespace: vs.Namespace, uid: vs.UID, priority: prio}
 	}
 	return routeRule{}
 }
 
 // Vet returns the list of generated notes
 func (v *VsHost) Vet() ([]*apiv1.Note, error) {
+	return v.VetContext(context.Background())
+}
+
+// VetContext returns the list of generated notes, or ctx.Err() once ctx is
+// done
+func (v *VsHost) VetContext(ctx context.Context) ([]*apiv1.Note, error) {
 	virtualServices, err := util.ListVirtualServicesInMesh(v.nsLister, v.vsLister)
 	if err != nil {
-		fmt.Printf("Error occurred retrieving VirtualServices: %s\n", err.Error())
+		glog.Errorf("Error occurred retrieving VirtualServices: %s", err)
 		return nil, err
 	}
-	notes, err := CreateVirtualServiceNotes(virtualServices)
+	notes, err := createVirtualServiceNotes(ctx, v.env, virtualServices)
 	if err != nil {
-		fmt.Printf("Error creating Conflicting VirtualService notes: %s\n", err.Error())
+		glog.Errorf("Error creating Conflicting VirtualService notes: %s", err)
 		return nil, err
 	}
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
@@ -429,11 +486,25 @@ func (v *VsHost) Info() *apiv1.Info {
 	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
 }
 
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes if the same host is defined in multiple virtual services.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.VirtualServices},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
 // NewVetter returns "VsHost" which implements the Vetter Tnterface
 func NewVetter(factory vetter.ResourceListGetter) *VsHost {
 	return &VsHost{
 		nsLister: factory.K8s().Core().V1().Namespaces().Lister(),
 		vsLister: factory.Istio().Networking().V1beta1().VirtualServices().Lister(),
+		env:      vetter.Environment(factory),
 	}
 }
 
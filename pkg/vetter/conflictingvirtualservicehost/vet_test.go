@@ -17,6 +17,8 @@ limitations under the License.
 package conflictingvirtualservicehost
 
 import (
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	istioNet "istio.io/api/networking/v1beta1"
@@ -27,6 +29,22 @@ import (
 	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 )
 
+// vsRefs returns references to the VirtualServices named "name.namespace".
+func vsRefs(vsNames ...string) []*apiv1.ObjectReference {
+	refs := []*apiv1.ObjectReference{}
+	for _, n := range vsNames {
+		parts := strings.SplitN(n, ".", 2)
+		refs = append(refs, util.ObjectRef(util.VirtualServiceKind, parts[1], parts[0], ""))
+	}
+	return refs
+}
+
+// setID sets the id and fingerprint of an expected note.
+func setID(n *apiv1.Note) {
+	n.Id = util.ComputeID(vetterID, n, n.Attr["host"], n.Attr["routes"])
+	n.Fingerprint = util.ComputeFingerprint(n)
+}
+
 var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 	Context("With fake VirtualServices", func() {
 		namespace := "bar"
@@ -259,11 +277,23 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"vs_names": "Vs1.bar, Vs2.bar",
 					"host":     "host2.bar.svc.cluster.local",
 					"routes":   "/foo exact /foo exact",
-				}}
-			expectedNote.Id = util.ComputeID(expectedNote)
+				},
+				Refs: vsRefs("Vs1.bar", "Vs2.bar")}
+			setID(expectedNote)
 			Expect(vsNotes[0]).To(Equal(expectedNote))
 		})
 
+		It("Generates the same note whatever the order of the VirtualServices", func() {
+			Vs1.Spec.Http = []*istioNet.HTTPRoute{&exactRoute}
+			Vs2.Spec.Http = []*istioNet.HTTPRoute{&exactRoute}
+			notes, err := CreateVirtualServiceNotes([]*istioClientNet.VirtualService{Vs1, Vs2})
+			Expect(err).NotTo(HaveOccurred())
+			reversed, err := CreateVirtualServiceNotes([]*istioClientNet.VirtualService{Vs2, Vs1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reversed).To(Equal(notes))
+			Expect(reversed[0].Attr["vs_names"]).To(Equal("Vs1.bar, Vs2.bar"))
+		})
+
 		It("Does not generate a note when two routes start with a different component", func() {
 			Vs1.Spec.Http = []*istioNet.HTTPRoute{&exactRoute2Levels}
 			Vs2.Spec.Http = []*istioNet.HTTPRoute{&exactRoute3Levels}
@@ -289,8 +319,9 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"vs_names": "Vs1.bar, Vs2.bar",
 					"host":     "host2.bar.svc.cluster.local",
 					"routes":   "/bar/foo prefix /bar/foo/baz exact",
-				}}
-			expectedNote.Id = util.ComputeID(expectedNote)
+				},
+				Refs: vsRefs("Vs1.bar", "Vs2.bar")}
+			setID(expectedNote)
 			Expect(vsNotes[0]).To(Equal(expectedNote))
 		})
 
@@ -309,8 +340,9 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 				Attr: map[string]string{
 					"host":     "host2.bar.svc.cluster.local",
 					"routes":   "/f* regex /foo/bar prefix",
-					"vs_names": "Vs1.bar, Vs2.bar"}}
-			expectedNote.Id = util.ComputeID(expectedNote)
+					"vs_names": "Vs1.bar, Vs2.bar"},
+				Refs: vsRefs("Vs1.bar", "Vs2.bar")}
+			setID(expectedNote)
 			Expect(vsNotes[0]).To(Equal(expectedNote))
 		})
 
@@ -338,6 +370,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "foo.com",
 					"routes":   "/foo prefix /foo/bar prefix",
 				},
+				Refs: vsRefs("Vs4.bar", "Vs8.bar"),
 			}
 
 			expectedNote2 := &apiv1.Note{
@@ -350,6 +383,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "foo.com",
 					"routes":   "/foo prefix /foo exact",
 				},
+				Refs: vsRefs("Vs4.bar", "Vs8.bar"),
 			}
 
 			expectedNote3 := &apiv1.Note{
@@ -362,6 +396,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"routes":   "/foo exact /foo exact",
 					"vs_names": "Vs4.bar, Vs8.bar",
 				},
+				Refs: vsRefs("Vs4.bar", "Vs8.bar"),
 			}
 
 			expectedNote4 := &apiv1.Note{
@@ -370,10 +405,11 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 				Msg:     vsHostMsg,
 				Level:   apiv1.NoteLevel_ERROR,
 				Attr: map[string]string{
-					"vs_names": "Vs8.bar, Vs11.bar",
+					"vs_names": "Vs11.bar, Vs8.bar",
 					"host":     "foo.com",
-					"routes":   "/foo exact /foo prefix",
+					"routes":   "/foo prefix /foo exact",
 				},
+				Refs: vsRefs("Vs11.bar", "Vs8.bar"),
 			}
 
 			expectedNote5 := &apiv1.Note{
@@ -386,6 +422,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "foo.com",
 					"routes":   "/foo prefix /foo/bar prefix",
 				},
+				Refs: vsRefs("Vs11.bar", "Vs8.bar"),
 			}
 
 			expectedNote6 := &apiv1.Note{
@@ -394,10 +431,11 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 				Msg:     vsHostMsg,
 				Level:   apiv1.NoteLevel_ERROR,
 				Attr: map[string]string{
-					"vs_names": "Vs4.bar, Vs11.bar",
+					"vs_names": "Vs11.bar, Vs4.bar",
 					"host":     "foo.com",
 					"routes":   "/foo prefix /foo prefix",
 				},
+				Refs: vsRefs("Vs11.bar", "Vs4.bar"),
 			}
 
 			expectedNote7 := &apiv1.Note{
@@ -407,9 +445,10 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 				Level:   apiv1.NoteLevel_ERROR,
 				Attr: map[string]string{
 					"host":     "foo.com",
-					"routes":   "/foo exact /foo prefix",
-					"vs_names": "Vs4.bar, Vs11.bar",
+					"routes":   "/foo prefix /foo exact",
+					"vs_names": "Vs11.bar, Vs4.bar",
 				},
+				Refs: vsRefs("Vs11.bar", "Vs4.bar"),
 			}
 
 			expectedNote8 := &apiv1.Note{
@@ -422,15 +461,16 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"routes":   "/foo exact /foo prefix",
 					"vs_names": "Vs4.bar, Vs4.bar",
 				},
+				Refs: vsRefs("Vs4.bar", "Vs4.bar"),
 			}
-			expectedNote1.Id = util.ComputeID(expectedNote1)
-			expectedNote2.Id = util.ComputeID(expectedNote2)
-			expectedNote3.Id = util.ComputeID(expectedNote3)
-			expectedNote4.Id = util.ComputeID(expectedNote4)
-			expectedNote5.Id = util.ComputeID(expectedNote5)
-			expectedNote6.Id = util.ComputeID(expectedNote6)
-			expectedNote7.Id = util.ComputeID(expectedNote7)
-			expectedNote8.Id = util.ComputeID(expectedNote8)
+			setID(expectedNote1)
+			setID(expectedNote2)
+			setID(expectedNote3)
+			setID(expectedNote4)
+			setID(expectedNote5)
+			setID(expectedNote6)
+			setID(expectedNote7)
+			setID(expectedNote8)
 
 			expecteds := []*apiv1.Note{expectedNote1, expectedNote2, expectedNote3, expectedNote4, expectedNote5,
 				expectedNote6, expectedNote7, expectedNote8,
@@ -483,6 +523,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "host2.bar.svc.cluster.local",
 					"routes":   "/foo prefix /foo/bar prefix",
 				},
+				Refs: vsRefs("Vs1.bar", "Vs1.bar"),
 			}
 			expectedNote2 := &apiv1.Note{
 				Type:    vsHostNoteType,
@@ -494,10 +535,11 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "host1.bar.svc.cluster.local",
 					"routes":   "/foo prefix /foo/bar prefix",
 				},
+				Refs: vsRefs("Vs1.bar", "Vs1.bar"),
 			}
 			expecteds := []*apiv1.Note{expectedNote1, expectedNote2}
-			expectedNote1.Id = util.ComputeID(expectedNote1)
-			expectedNote2.Id = util.ComputeID(expectedNote2)
+			setID(expectedNote1)
+			setID(expectedNote2)
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(vsNotes).To(HaveLen(2))
@@ -541,6 +583,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "bar.com",
 					"routes":   "/foo prefix /foo exact",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs2.bar"),
 			}
 			expectedNote2 := &apiv1.Note{
 				Type:    vsHostNoteType,
@@ -552,6 +595,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"vs_names": "fooBarVs1.bar, fooBarVs2.bar",
 					"host":     "bar.com",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs2.bar"),
 			}
 
 			expectedNote3 := &apiv1.Note{
@@ -564,6 +608,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "foo.com",
 					"routes":   "/foo exact /foo exact",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs2.bar"),
 			}
 
 			expectedNote4 := &apiv1.Note{
@@ -576,6 +621,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "foo.com",
 					"routes":   "/foo prefix /foo exact",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs2.bar"),
 			}
 
 			expectedNote5 := &apiv1.Note{
@@ -588,6 +634,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"host":     "foo.com",
 					"routes":   "/foo prefix /foo/bar prefix",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs2.bar"),
 			}
 
 			expectedNote6 := &apiv1.Note{
@@ -600,6 +647,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"vs_names": "fooBarVs1.bar, fooBarVs2.bar",
 					"host":     "bar.com",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs2.bar"),
 			}
 
 			expectedNote7 := &apiv1.Note{
@@ -612,6 +660,7 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"vs_names": "fooBarVs1.bar, fooBarVs1.bar",
 					"host":     "bar.com",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs1.bar"),
 			}
 
 			expectedNote8 := &apiv1.Note{
@@ -624,19 +673,20 @@ var _ = Describe("Conflicting Virtual Service Host Vet Notes", func() {
 					"vs_names": "fooBarVs1.bar, fooBarVs1.bar",
 					"host":     "foo.com",
 				},
+				Refs: vsRefs("fooBarVs1.bar", "fooBarVs1.bar"),
 			}
 
 			expecteds := []*apiv1.Note{expectedNote1, expectedNote2, expectedNote3, expectedNote4, expectedNote5,
 				expectedNote6, expectedNote7, expectedNote8,
 			}
-			expectedNote1.Id = util.ComputeID(expectedNote1)
-			expectedNote2.Id = util.ComputeID(expectedNote2)
-			expectedNote3.Id = util.ComputeID(expectedNote3)
-			expectedNote4.Id = util.ComputeID(expectedNote4)
-			expectedNote5.Id = util.ComputeID(expectedNote5)
-			expectedNote6.Id = util.ComputeID(expectedNote6)
-			expectedNote7.Id = util.ComputeID(expectedNote7)
-			expectedNote8.Id = util.ComputeID(expectedNote8)
+			setID(expectedNote1)
+			setID(expectedNote2)
+			setID(expectedNote3)
+			setID(expectedNote4)
+			setID(expectedNote5)
+			setID(expectedNote6)
+			setID(expectedNote7)
+			setID(expectedNote8)
 
 			vsNotes, err := CreateVirtualServiceNotes(vsList)
 
@@ -20,6 +20,7 @@ limitations under the License.
 package danglingroutedestinationhost
 
 import (
+	_ "embed"
 	"strings"
 
 	istioClientNet "istio.io/client-go/pkg/apis/networking/v1beta1"
@@ -42,30 +43,47 @@ const (
 		" from the VirtualService resource."
 )
 
+//go:embed README-dangling-route-destination.md
+var danglingRouteDestinationDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        danglingRouteDestinationHostNoteType,
+		Code:        "IV0601",
+		Vetter:      vetterID,
+		Remediation: "Create the missing services or update the route destinations of the VirtualService to existing services.",
+		DocsURL:     vetter.DocsURL("danglingroutedestinationhost", danglingRouteDestinationHostNoteType),
+		Doc:         danglingRouteDestinationDoc,
+	},
+}
+
 // DanglingRouteDestinationHost implements Vetter interface
 type DanglingRouteDestinationHost struct {
 	nsLister  v1.NamespaceLister
 	svcLister v1.ServiceLister
 	vsLister  istioNetListers.VirtualServiceLister
+	env       util.Environment
 }
 
-func createServiceMap(svcs []*corev1.Service) map[string]bool {
+func createServiceMap(env util.Environment, svcs []*corev1.Service) map[string]bool {
 	serviceMap := map[string]bool{}
 	for _, s := range svcs {
-		key := s.Name + "." + s.Namespace + util.KubernetesDomainSuffix
+		key := s.Name + "." + s.Namespace + env.DomainSuffix()
 		serviceMap[key] = true
 	}
 	return serviceMap
 }
 
 // createDanglingRouteHostNotes creates notes for VirtualService(s) which have
-// dangling route hostname(s).
-func createDanglingRouteHostNotes(svcs []*corev1.Service,
+// dangling route hostname(s). Hosts outside the cluster domain of env aren't
+// services of the cluster and are ignored.
+func createDanglingRouteHostNotes(env util.Environment, svcs []*corev1.Service,
 	vsList []*istioClientNet.VirtualService) []*apiv1.Note {
 	var err error
 	var host string
 	notes := []*apiv1.Note{}
-	svcMap := createServiceMap(svcs)
+	svcMap := createServiceMap(env, svcs)
 	for _, vs := range vsList {
 		danglingHostnames := []string{}
 		for _, routes := range vs.Spec.GetHttp() {
@@ -73,9 +91,9 @@ func createDanglingRouteHostNotes(svcs []*corev1.Service,
 				if d := dw.GetDestination(); d != nil {
 					host = d.GetHost()
 					if len(host) > 0 {
-						host, err = util.ConvertHostnameToFQDN(host, vs.Namespace)
+						host, err = env.ConvertHostnameToFQDN(host, vs.Namespace)
 						if err == nil &&
-							strings.HasSuffix(host, util.KubernetesDomainSuffix) {
+							strings.HasSuffix(host, env.DomainSuffix()) {
 							if _, ok := svcMap[host]; !ok {
 								danglingHostnames = append(danglingHostnames, d.GetHost())
 							}
@@ -95,12 +113,16 @@ func createDanglingRouteHostNotes(svcs []*corev1.Service,
 					"namespace":     vs.Namespace,
 					"hostname_list": strings.Join(danglingHostnames, ","),
 				},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.VirtualServiceKind, vs.Namespace, vs.Name, vs.UID),
+				},
 			})
 		}
 	}
 
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 
 	return notes
@@ -118,7 +140,8 @@ func (r *DanglingRouteDestinationHost) Vet() ([]*apiv1.Note, error) {
 		return nil, err
 	}
 
-	notes := createDanglingRouteHostNotes(svcs, vsList)
+	notes := createDanglingRouteHostNotes(r.env, svcs, vsList)
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
@@ -127,12 +150,26 @@ func (r *DanglingRouteDestinationHost) Info() *apiv1.Info {
 	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
 }
 
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes if route destination hosts of virtual services point to services which don't exist.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Services, vetter.VirtualServices},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
 // NewVetter returns "DanglingRouteDestinationHost" which implements Vetter Interface
 func NewVetter(factory vetter.ResourceListGetter) *DanglingRouteDestinationHost {
 	return &DanglingRouteDestinationHost{
 		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
 		svcLister: factory.K8s().Core().V1().Services().Lister(),
 		vsLister:  factory.Istio().Networking().V1beta1().VirtualServices().Lister(),
+		env:       vetter.Environment(factory),
 	}
 }
 
@@ -30,7 +30,7 @@ import (
 
 var _ = Describe("Vet", func() {
 	It("creates zero notes on empty lists", func() {
-		notes := createDanglingRouteHostNotes(nil, nil)
+		notes := createDanglingRouteHostNotes(util.Environment{}, nil, nil)
 		Expect(notes).To(HaveLen(0))
 	})
 
@@ -113,7 +113,7 @@ var _ = Describe("Vet", func() {
 				},
 			},
 		}
-		notes := createDanglingRouteHostNotes(svcs, vsList)
+		notes := createDanglingRouteHostNotes(util.Environment{}, svcs, vsList)
 		Expect(notes).To(HaveLen(0))
 	})
 
@@ -232,6 +232,9 @@ var _ = Describe("Vet", func() {
 					"namespace":     "team-bar",
 					"hostname_list": "bar.team-baz.svc.cluster.local,baz",
 				},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.VirtualServiceKind, "team-bar", "bar", ""),
+				},
 			},
 			&apiv1.Note{
 				Type:    danglingRouteDestinationHostNoteType,
@@ -243,12 +246,67 @@ var _ = Describe("Vet", func() {
 					"namespace":     "team-bah",
 					"hostname_list": "bah.team-bah.svc.cluster.local",
 				},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.VirtualServiceKind, "team-bah", "bah", ""),
+				},
 			},
 		}
 		for i := range expNotes {
-			expNotes[i].Id = util.ComputeID(expNotes[i])
+			expNotes[i].Id = util.ComputeID(vetterID, expNotes[i])
+			expNotes[i].Fingerprint = util.ComputeFingerprint(expNotes[i])
 		}
-		notes := createDanglingRouteHostNotes(svcs, vsList)
+		notes := createDanglingRouteHostNotes(util.Environment{}, svcs, vsList)
 		Expect(notes).To(Equal(expNotes))
 	})
+
+	It("uses the cluster domain of the environment", func() {
+		svcs := []*corev1.Service{
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "team-foo",
+				},
+			},
+		}
+		vsList := []*istioClientNet.VirtualService{
+			&istioClientNet.VirtualService{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "team-foo",
+				},
+				Spec: istioNet.VirtualService{
+					Http: []*istioNet.HTTPRoute{
+						&istioNet.HTTPRoute{
+							Route: []*istioNet.HTTPRouteDestination{
+								&istioNet.HTTPRouteDestination{
+									Destination: &istioNet.Destination{
+										Host: "foo",
+									},
+								},
+								&istioNet.HTTPRouteDestination{
+									Destination: &istioNet.Destination{
+										Host: "foo.team-foo.svc.corp.example",
+									},
+								},
+								&istioNet.HTTPRouteDestination{
+									Destination: &istioNet.Destination{
+										Host: "bar.team-foo.svc.corp.example",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		env := util.Environment{ClusterDomain: "corp.example"}
+		notes := createDanglingRouteHostNotes(env, svcs, vsList)
+		Expect(notes).To(HaveLen(1))
+		Expect(notes[0].Attr).To(HaveKeyWithValue("hostname_list", "bar.team-foo.svc.corp.example"))
+
+		// With the default cluster domain, these hosts aren't services of the
+		// cluster.
+		notes = createDanglingRouteHostNotes(util.Environment{}, svcs, vsList[:1])
+		Expect(notes).To(BeEmpty())
+	})
 })
@@ -2,14 +2,20 @@ package meshversion
 
 import (
 	"fmt"
+	"io/ioutil"
 	"sort"
+	"strings"
 
+	"github.com/ghodss/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 func pod(name, namespace, scImage, initImage string) *corev1.Pod {
@@ -75,7 +81,7 @@ var _ = Describe("Meshversion", func() {
 
 			pods = append(pods, a, b, c, d)
 
-			iImages := injectImages{
+			iImages := util.InjectImages{
 				Init:    imagedot8,
 				Sidecar: imagedot8,
 			}
@@ -119,6 +125,100 @@ var _ = Describe("Meshversion", func() {
 			Expect(notes[3].Msg).To(Equal(initMismatchMsg))
 
 		})
+
+		It("returns fixes rolling out the pods of workloads", func() {
+			imagedot8 := "docker.io/istio/proxy_init:0.8.0"
+			image1dot0 := "docker.io/istio/proxy_init:1.0.0"
+			iImages := util.InjectImages{Init: imagedot8, Sidecar: imagedot8}
+
+			a := pod("web-5d9f8-abcde", "namespace1", image1dot0, image1dot0)
+			a.Labels = map[string]string{"pod-template-hash": "5d9f8"}
+			a.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "web-5d9f8",
+				Controller: &[]bool{true}[0],
+			}}
+			b := pod("bare", "namespace1", image1dot0, imagedot8)
+
+			notes := sortNotes(vetPods([]*corev1.Pod{a, b}, iImages))
+			Expect(notes).To(HaveLen(3))
+			Expect(notes[0].Attr["pod_name"]).To(Equal("bare"))
+			Expect(notes[0].Fix).To(BeNil())
+
+			Expect(notes[1].Fix).NotTo(BeNil())
+			Expect(notes[1].Fix.Target.Kind).To(Equal("Deployment"))
+			Expect(notes[1].Fix.Target.Name).To(Equal("web"))
+			Expect(notes[1].Fix.Type).To(Equal(apiv1.PatchType_STRATEGIC_MERGE_PATCH))
+			Expect(notes[1].Fix.Patch).To(Equal(`{"spec":{"template":{"metadata":{"annotations":{"` +
+				restartAnnotation + `":"docker.io/istio/proxy_init:0.8.0"}}}}}`))
+			// Both notes on the pod roll out the deployment once.
+			Expect(notes[2].Fix).To(Equal(notes[1].Fix))
+		})
 	})
 
 })
+
+// configMapFromFile reads a ConfigMap from the util test data.
+func configMapFromFile(file string) *corev1.ConfigMap {
+	b, err := ioutil.ReadFile("../util/testdata/1.1/" + file)
+	Expect(err).NotTo(HaveOccurred())
+	var cm corev1.ConfigMap
+	Expect(yaml.Unmarshal(b, &cm)).To(Succeed())
+	return &cm
+}
+
+var _ = Describe("Meshversion with Istio revisions", func() {
+	It("compares pods with the images of their revision", func() {
+		cms := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		injector := configMapFromFile("istio-sidecar-injector.yaml")
+		mesh := configMapFromFile("mesh-config.yaml")
+		canaryInjector := injector.DeepCopy()
+		canaryInjector.Name = "istio-sidecar-injector-canary"
+		canaryInjector.Data["config"] = strings.ReplaceAll(canaryInjector.Data["config"], "1.1.2", "1.2.0")
+		canaryMesh := mesh.DeepCopy()
+		canaryMesh.Name = "istio-canary"
+		for _, cm := range []*corev1.ConfigMap{injector, mesh, canaryInjector, canaryMesh} {
+			Expect(cms.Add(cm)).To(Succeed())
+		}
+
+		nss := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(nss.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "stable", Labels: map[string]string{"istio-injection": "enabled"}}})).To(Succeed())
+		Expect(nss.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "canary", Labels: map[string]string{"istio.io/rev": "canary"}}})).To(Succeed())
+
+		injected := func(p *corev1.Pod) *corev1.Pod {
+			p.Annotations = map[string]string{"sidecar.istio.io/status": "{}"}
+			return p
+		}
+		v112 := "docker.io/istio/proxyv2:1.1.2"
+		init112 := "docker.io/istio/proxy_init:1.1.2"
+		v120 := "docker.io/istio/proxyv2:1.2.0"
+		init120 := "docker.io/istio/proxy_init:1.2.0"
+		pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		for _, p := range []*corev1.Pod{
+			injected(pod("current", "stable", v112, init112)),
+			injected(pod("current", "canary", v120, init120)),
+			injected(pod("outdated", "canary", v112, init112)),
+		} {
+			Expect(pods.Add(p)).To(Succeed())
+		}
+
+		m := &MeshVersion{
+			podLister: v1.NewPodLister(pods),
+			cmLister:  v1.NewConfigMapLister(cms),
+			nsLister:  v1.NewNamespaceLister(nss),
+		}
+		notes, err := m.vetInjectedImages()
+		Expect(err).NotTo(HaveOccurred())
+		notes = sortNotes(notes)
+		Expect(notes).To(HaveLen(2))
+		for _, n := range notes {
+			Expect(n.Attr["namespace"]).To(Equal("canary"))
+			Expect(n.Attr["pod_name"]).To(Equal("outdated"))
+		}
+		Expect(notes[0].Attr["inject_sidecar_image"]).To(Equal(v120))
+		Expect(notes[1].Attr["inject_init_image"]).To(Equal(init120))
+	})
+})
@@ -19,13 +19,13 @@ limitations under the License.
 package meshversion
 
 import (
-	"errors"
+	_ "embed"
+	"sort"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
 	"github.com/aspenmesh/istio-vet/pkg/vetter"
 	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/client-go/listers/core/v1"
 )
@@ -44,45 +44,65 @@ const (
 		" is running with istio-init image ${init_image}" +
 		" but your environment is injecting ${inject_init_image} for" +
 		" new workloads. Consider upgrading the istio-init container in the pod."
+	// restartAnnotation is set on the pod template of workloads to roll out
+	// pods with the injected images.
+	restartAnnotation = "vet.aspenmesh.io/injected-sidecar-image"
 )
 
+//go:embed README-sidecar-image-mismatch.md
+var sidecarImageMismatchDoc string
+
+//go:embed README-init-image-mismatch.md
+var initImageMismatchDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        sidecarMismatchNoteType,
+		Code:        "IV0201",
+		Vetter:      vetterID,
+		Remediation: "Restart the pod, e.g. with a rollout of its deployment, so it is injected with the current sidecar image.",
+		DocsURL:     vetter.DocsURL("meshversion", sidecarMismatchNoteType),
+		Doc:         sidecarImageMismatchDoc,
+	},
+	{
+		Type:        initMismatchNoteType,
+		Code:        "IV0202",
+		Vetter:      vetterID,
+		Remediation: "Restart the pod, e.g. with a rollout of its deployment, so it is injected with the current istio-init image.",
+		DocsURL:     vetter.DocsURL("meshversion", initMismatchNoteType),
+		Doc:         initImageMismatchDoc,
+	},
+}
+
 // MeshVersion implements Vetter interface
 type MeshVersion struct {
 	podLister v1.PodLister
 	cmLister  v1.ConfigMapLister
 	nsLister  v1.NamespaceLister
+	env       util.Environment
 }
 
-type injectImages struct {
-	Init    string
-	Sidecar string
-}
-
-func getInjectImages(cmLister v1.ConfigMapLister) (injectImages, error) {
-	scInjectSpec, err := util.GetInitializerSidecarSpec(cmLister)
-
-	if err != nil {
-		return injectImages{}, err
-	}
-	if len(scInjectSpec.InitContainers) > 0 && len(scInjectSpec.Containers) > 0 {
-		return injectImages{
-			Init:    scInjectSpec.InitContainers[0].Image,
-			Sidecar: scInjectSpec.Containers[0].Image,
-		}, nil
-	} else {
-		errStr := "Failed to get inject images"
-		glog.Error(errStr)
-		return injectImages{}, errors.New(errStr)
+// restartFix returns a Fix rolling out the pods of the workload managing p,
+// so they are injected with images, or nil if p isn't managed by a workload.
+// The patch only depends on the injected images, so the fixes of all notes
+// on the pods of a workload are the same.
+func restartFix(p *corev1.Pod, images util.InjectImages) *apiv1.Fix {
+	w := util.Workload(p)
+	if w == nil {
+		return nil
 	}
+	return util.PodTemplateFix("Roll out the pods of "+w.GetKind()+" "+w.GetName(),
+		w, nil, map[string]string{restartAnnotation: images.Sidecar})
 }
 
 // Separated for unit tests
-func vetPods(pods []*corev1.Pod, injImages injectImages) []*apiv1.Note {
+func vetPods(pods []*corev1.Pod, injImages util.InjectImages) []*apiv1.Note {
 	notes := []*apiv1.Note{}
 
 	for _, p := range pods {
 
-		sidecarImage, err := util.Image(util.IstioProxyContainerName, p.Spec)
+		sidecarImage, err := util.ProxyImage(p.Spec)
 		if err == nil && sidecarImage != injImages.Sidecar {
 			notes = append(notes, &apiv1.Note{
 				Type:    sidecarMismatchNoteType,
@@ -93,7 +113,10 @@ func vetPods(pods []*corev1.Pod, injImages injectImages) []*apiv1.Note {
 					"pod_name":             p.Name,
 					"namespace":            p.Namespace,
 					"sidecar_image":        sidecarImage,
-					"inject_sidecar_image": injImages.Sidecar}})
+					"inject_sidecar_image": injImages.Sidecar},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.PodKind, p.Namespace, p.Name, p.UID)},
+				Fix: restartFix(p, injImages)})
 		}
 
 		initImage, err := util.InitImage(util.IstioInitContainerName, p.Spec)
@@ -107,37 +130,59 @@ func vetPods(pods []*corev1.Pod, injImages injectImages) []*apiv1.Note {
 					"pod_name":          p.Name,
 					"namespace":         p.Namespace,
 					"init_image":        initImage,
-					"inject_init_image": injImages.Init}})
+					"inject_init_image": injImages.Init},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.PodKind, p.Namespace, p.Name, p.UID)},
+				Fix: restartFix(p, injImages)})
 		}
 	}
 
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 	return notes
 }
 
 // The istio-sidecar-injector ConfigMap has the sidecar & init images that will be injected into all new deployments, daemonsets, ....  If that doesn't match the images that are in existing Pods, emit a warning.
+// Every Istio revision has its own istio-sidecar-injector-<revision> ConfigMap, pods are compared with the one of the revision which injected them.
 func (m *MeshVersion) vetInjectedImages() ([]*apiv1.Note, error) {
 	notes := []*apiv1.Note{}
 
-	injImages, err := getInjectImages(m.cmLister)
-	if err != nil {
-		if n := util.IstioInitializerDisabledNote(err.Error(), vetterID,
-			sidecarMismatchNoteType); n != nil {
-			notes = append(notes, n)
-		}
-		return notes, nil
-	}
-
 	pods, err := util.ListPodsInMesh(m.nsLister, m.podLister)
 	if err != nil {
 		// If err != nil when getting pod data, the lower-level error has already
 		// been logged and handled.
 		return nil, err
 	}
-
-	return vetPods(pods, injImages), nil
+	podsByRevision := map[string][]*corev1.Pod{}
+	for _, p := range pods {
+		ns, _ := m.nsLister.Get(p.Namespace)
+		rev := util.PodRevision(p, ns)
+		podsByRevision[rev] = append(podsByRevision[rev], p)
+	}
+	if len(podsByRevision) == 0 {
+		// Still report a disabled initializer without pods in the mesh.
+		podsByRevision[util.IstioDefaultRevision] = nil
+	}
+	revisions := make([]string, 0, len(podsByRevision))
+	for rev := range podsByRevision {
+		revisions = append(revisions, rev)
+	}
+	sort.Strings(revisions)
+
+	for _, rev := range revisions {
+		injImages, err := m.env.GetRevisionInjectImages(m.cmLister, rev)
+		if err != nil {
+			if n := util.IstioInitializerDisabledNote(err.Error(), vetterID,
+				sidecarMismatchNoteType); n != nil {
+				notes = append(notes, n)
+			}
+			continue
+		}
+		notes = append(notes, vetPods(podsByRevision[rev], injImages)...)
+	}
+	return notes, nil
 }
 
 // Vet returns the list of generated notes
@@ -147,6 +192,7 @@ func (m *MeshVersion) Vet() ([]*apiv1.Note, error) {
 	if err == nil {
 		notes = append(notes, injectedNotes...)
 	}
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
@@ -155,12 +201,26 @@ func (m *MeshVersion) Info() *apiv1.Info {
 	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
 }
 
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes if pods run sidecar or init images other than the injected ones.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Pods, vetter.ConfigMaps},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
 // NewVetter returns "MeshVersion" which implements Vetter Interface
 func NewVetter(factory vetter.ResourceListGetter) *MeshVersion {
 	return &MeshVersion{
 		podLister: factory.K8s().Core().V1().Pods().Lister(),
 		cmLister:  factory.K8s().Core().V1().ConfigMaps().Lister(),
 		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
+		env:       vetter.Environment(factory),
 	}
 }
 
@@ -0,0 +1,13 @@
+package missingsidecar
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMissingSidecar(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MissingSidecar Suite")
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package missingsidecar vets the pods in namespaces with sidecar injection
+// enabled and generates notes on pods without a sidecar proxy.
+package missingsidecar
+
+import (
+	_ "embed"
+	"encoding/json"
+	"time"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	vetterID         = "MissingSidecar"
+	predatesNoteType = "pod-predates-injection"
+	predatesSummary  = "Pod created before sidecar injection - ${pod_name}"
+	predatesMsg      = "The pod ${pod_name} in namespace ${namespace} has no sidecar proxy" +
+		" because it was created before sidecar injection was enabled for Istio revision" +
+		" ${revision}. Consider restarting ${owner} to inject the sidecar."
+	injectionFailedNoteType = "sidecar-injection-failed"
+	injectionFailedSummary  = "Sidecar injection failed - ${pod_name}"
+	injectionFailedMsg      = "The pod ${pod_name} in namespace ${namespace} has no sidecar proxy" +
+		" although it was created after sidecar injection was enabled for Istio revision" +
+		" ${revision}. The sidecar injector webhook of the revision may have failed or been" +
+		" unreachable. Check the injector and consider restarting ${owner}."
+	optedOutNoteType = "sidecar-opted-out"
+	optedOutSummary  = "Pod opted out of sidecar injection - ${pod_name}"
+	optedOutMsg      = "The pod ${pod_name} in namespace ${namespace} has no sidecar proxy" +
+		" although injection is enabled for its namespace: ${reason}."
+	// restartAnnotation is set on the pod template of workloads to roll out
+	// pods once injection is enabled.
+	restartAnnotation = "vet.aspenmesh.io/injection-enabled"
+)
+
+//go:embed README-pod-predates-injection.md
+var predatesDoc string
+
+//go:embed README-sidecar-injection-failed.md
+var injectionFailedDoc string
+
+//go:embed README-sidecar-opted-out.md
+var optedOutDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        predatesNoteType,
+		Code:        "IV0801",
+		Vetter:      vetterID,
+		Remediation: "Restart the pod, e.g. with a rollout of its deployment, so it is injected with the sidecar.",
+		DocsURL:     vetter.DocsURL("missingsidecar", predatesNoteType),
+		Doc:         predatesDoc,
+	},
+	{
+		Type:        injectionFailedNoteType,
+		Code:        "IV0802",
+		Vetter:      vetterID,
+		Remediation: "Check that the sidecar injector webhook is running and reachable, then restart the pod.",
+		DocsURL:     vetter.DocsURL("missingsidecar", injectionFailedNoteType),
+		Doc:         injectionFailedDoc,
+	},
+	{
+		Type:        optedOutNoteType,
+		Code:        "IV0803",
+		Vetter:      vetterID,
+		Remediation: "Informational, remove the opt out if the pod should be in the mesh.",
+		DocsURL:     vetter.DocsURL("missingsidecar", optedOutNoteType),
+		Doc:         optedOutDoc,
+	},
+}
+
+// MissingSidecar implements Vetter interface
+type MissingSidecar struct {
+	nsLister  v1.NamespaceLister
+	podLister v1.PodLister
+	cmLister  v1.ConfigMapLister
+	env       util.Environment
+}
+
+// labelTime returns when the last of the labels keys was set on obj, read
+// from its managed fields, or its creation if no manager owns them. The time
+// of a managed fields entry is when its manager last wrote any of the fields
+// it owns, so it is only trusted if the entry owns nothing but the labels
+// keys or wasn't written since obj was created. Otherwise when the labels
+// were set can't be told and false is returned.
+func labelTime(obj metav1.Object, keys ...string) (time.Time, bool) {
+	created := obj.GetCreationTimestamp().Time
+	t := created
+	for _, mf := range obj.GetManagedFields() {
+		if mf.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]map[string]map[string]interface{}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		labels := fields["f:metadata"]["f:labels"]
+		owned := false
+		for _, k := range keys {
+			if _, ok := labels["f:"+k]; ok {
+				owned = true
+			}
+		}
+		if !owned {
+			continue
+		}
+		if mf.Time == nil {
+			return time.Time{}, false
+		}
+		if mf.Time.Time.After(created) && !ownsOnly(fields, keys) {
+			return time.Time{}, false
+		}
+		if mf.Time.Time.After(t) {
+			t = mf.Time.Time
+		}
+	}
+	return t, true
+}
+
+// ownsOnly returns true if the managed fields fields hold nothing but the
+// labels keys.
+func ownsOnly(fields map[string]map[string]map[string]interface{}, keys []string) bool {
+	if len(fields) != 1 || len(fields["f:metadata"]) != 1 {
+		return false
+	}
+	for f := range fields["f:metadata"]["f:labels"] {
+		if f == "." {
+			continue
+		}
+		found := false
+		for _, k := range keys {
+			if f == "f:"+k {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// injectionEnabledTime returns when injection was enabled for a pod: when
+// the injection label was set on its namespace ns and when the injector
+// configmap of its revision was created, whichever is later. injector may be
+// nil if the revision has no injector. The zero time is returned if when
+// the label was set can't be told.
+func injectionEnabledTime(ns *corev1.Namespace, injector *corev1.ConfigMap) time.Time {
+	var t time.Time
+	if ns != nil && util.NamespaceInMesh(ns) {
+		lt, ok := labelTime(ns, util.IstioInjectionLabel, util.IstioRevisionLabel)
+		if !ok {
+			return time.Time{}
+		}
+		t = lt
+	}
+	if injector != nil && injector.CreationTimestamp.After(t) {
+		t = injector.CreationTimestamp.Time
+	}
+	return t
+}
+
+// owner returns the workload managing p, named like "Deployment web", and a
+// reference to it, or "the pod" and nil if p isn't managed by a workload.
+func owner(p *corev1.Pod) (string, *apiv1.ObjectReference) {
+	if w := util.Workload(p); w != nil {
+		return w.GetKind() + " " + w.GetName(), w
+	}
+	return "the pod", nil
+}
+
+// restartFix returns a Fix rolling out the pods of workload w, so they are
+// injected with the sidecar, or nil if w is nil. The patch only depends on
+// when injection was enabled, so the fixes of all pods of a workload are the
+// same.
+func restartFix(w *apiv1.ObjectReference, enabled time.Time) *apiv1.Fix {
+	if w == nil {
+		return nil
+	}
+	return util.PodTemplateFix("Roll out the pods of "+w.GetKind()+" "+w.GetName(),
+		w, nil, map[string]string{restartAnnotation: enabled.UTC().Format(time.RFC3339)})
+}
+
+// createNotes returns the notes on the pods of memberships without a sidecar
+// in namespaces or revisions with injection enabled. Namespaces are looked
+// up in namespaces by name, the injector configmaps in injectors by
+// revision. Pods of revisions without injector, e.g. out of the vetted
+// namespaces, aren't reported, neither are pods which can't be told to
+// predate injection or not, because when they or injection were created
+// isn't known, e.g. when vetting manifest files.
+func createNotes(memberships []util.MeshMembership, namespaces map[string]*corev1.Namespace,
+	injectors map[string]*corev1.ConfigMap) []*apiv1.Note {
+	notes := []*apiv1.Note{}
+	for _, m := range memberships {
+		p := m.Pod
+		if m.Injected || p.DeletionTimestamp != nil ||
+			p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		switch m.Reason {
+		case util.ReasonNamespaceIgnored, util.ReasonNamespaceNotInMesh, util.ReasonHostNetwork:
+			continue
+		}
+		injector := injectors[m.Revision]
+		if injector == nil {
+			continue
+		}
+		enabled := injectionEnabledTime(namespaces[p.Namespace], injector)
+		if m.InjectionRequired && (enabled.IsZero() || p.CreationTimestamp.IsZero()) {
+			continue
+		}
+
+		ownerName, w := owner(p)
+		n := &apiv1.Note{
+			Attr: map[string]string{
+				"pod_name":  p.Name,
+				"namespace": p.Namespace,
+				"revision":  m.Revision,
+				"owner":     ownerName,
+			},
+			Refs: []*apiv1.ObjectReference{
+				util.ObjectRef(util.PodKind, p.Namespace, p.Name, p.UID)},
+		}
+		switch {
+		case !m.InjectionRequired:
+			n.Type, n.Summary, n.Msg, n.Level = optedOutNoteType, optedOutSummary, optedOutMsg, apiv1.NoteLevel_INFO
+			n.Attr["reason"] = m.Detail
+		case p.CreationTimestamp.Time.Before(enabled):
+			n.Type, n.Summary, n.Msg, n.Level = predatesNoteType, predatesSummary, predatesMsg, apiv1.NoteLevel_WARNING
+			n.Fix = restartFix(w, enabled)
+		default:
+			n.Type, n.Summary, n.Msg, n.Level = injectionFailedNoteType, injectionFailedSummary, injectionFailedMsg,
+				apiv1.NoteLevel_ERROR
+			n.Fix = restartFix(w, enabled)
+		}
+		notes = append(notes, n)
+	}
+
+	for i := range notes {
+		notes[i].Id = util.ComputeID(vetterID, notes[i])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
+	}
+	return notes
+}
+
+// Vet returns the list of generated notes
+func (m *MissingSidecar) Vet() ([]*apiv1.Note, error) {
+	memberships, err := m.env.ListPodMeshMemberships(m.nsLister, m.podLister, m.cmLister)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := map[string]*corev1.Namespace{}
+	injectors := map[string]*corev1.ConfigMap{}
+	for _, ms := range memberships {
+		if _, ok := namespaces[ms.Pod.Namespace]; !ok {
+			namespaces[ms.Pod.Namespace], _ = m.nsLister.Get(ms.Pod.Namespace)
+		}
+		if _, ok := injectors[ms.Revision]; !ok {
+			injectors[ms.Revision], _ = m.env.GetRevisionInitializerConfigMap(m.cmLister, ms.Revision)
+		}
+	}
+	notes := createNotes(memberships, namespaces, injectors)
+	vetter.Annotate(notes, NoteTypes)
+	return notes, nil
+}
+
+// Info returns information about the vetter
+func (m *MissingSidecar) Info() *apiv1.Info {
+	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
+}
+
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes on pods without sidecar in namespaces with sidecar injection enabled.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Pods, vetter.ConfigMaps},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
+// NewVetter returns "MissingSidecar" which implements Vetter Interface
+func NewVetter(factory vetter.ResourceListGetter) *MissingSidecar {
+	return &MissingSidecar{
+		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
+		podLister: factory.K8s().Core().V1().Pods().Lister(),
+		cmLister:  factory.K8s().Core().V1().ConfigMaps().Lister(),
+		env:       vetter.Environment(factory),
+	}
+}
+
+// NewVetterFromListers returns a MissingSidecar vetter listing resources
+// from the listers, with the Istio configuration in env.
+func NewVetterFromListers(nsLister v1.NamespaceLister, podLister v1.PodLister, cmLister v1.ConfigMapLister,
+	env util.Environment) *MissingSidecar {
+	return &MissingSidecar{
+		nsLister:  nsLister,
+		podLister: podLister,
+		cmLister:  cmLister,
+		env:       env,
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package missingsidecar
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+)
+
+var (
+	created  = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	labelled = created.Add(24 * time.Hour)
+)
+
+// namespace returns a namespace created at created, whose injection label
+// was set at labelled.
+func namespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		Labels:            map[string]string{util.IstioInjectionLabel: "enabled"},
+		CreationTimestamp: metav1.NewTime(created),
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{
+				Manager:  "kubectl-create",
+				Time:     &metav1.Time{Time: created},
+				FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:phase":{}}}`)},
+			},
+			{
+				Manager:  "kubectl-label",
+				Time:     &metav1.Time{Time: labelled},
+				FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{".":{},"f:istio-injection":{}}}}`)},
+			},
+		},
+	}}
+}
+
+// pod returns a pod of Deployment name created at t.
+func pod(namespace, name string, t time.Time) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         namespace,
+		Name:              name + "-5d4f8-abcde",
+		CreationTimestamp: metav1.NewTime(t),
+		Labels:            map[string]string{"pod-template-hash": "5d4f8"},
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "ReplicaSet", Name: name + "-5d4f8", Controller: &controller},
+		},
+	}}
+}
+
+// injector returns the injector configmap of the default revision, created
+// with the namespace.
+func injector() *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         "istio-system",
+		Name:              util.IstioInitializerConfigMap,
+		CreationTimestamp: metav1.NewTime(created),
+	}}
+}
+
+func injected(p *corev1.Pod) *corev1.Pod {
+	p.Annotations = map[string]string{util.IstioInitializerPodAnnotation: "{}"}
+	p.Spec.Containers = []corev1.Container{{Name: util.IstioProxyContainerName}}
+	return p
+}
+
+var _ = Describe("MissingSidecar", func() {
+	It("reads when labels were set from the managed fields", func() {
+		t, ok := labelTime(namespace("web"), util.IstioInjectionLabel)
+		Expect(ok).To(BeTrue())
+		Expect(t).To(Equal(labelled))
+		// Labels no manager owns were set when the namespace was created.
+		t, ok = labelTime(namespace("web"), util.IstioRevisionLabel)
+		Expect(ok).To(BeTrue())
+		Expect(t).To(Equal(created))
+
+		// The time of a manager owning other fields since may be when it
+		// wrote those.
+		applied := namespace("web")
+		applied.ManagedFields[1].FieldsV1.Raw = []byte(
+			`{"f:metadata":{"f:labels":{".":{},"f:istio-injection":{},"f:team":{}}}}`)
+		_, ok = labelTime(applied, util.IstioInjectionLabel)
+		Expect(ok).To(BeFalse())
+		Expect(injectionEnabledTime(applied, nil).IsZero()).To(BeTrue())
+		applied.ManagedFields[1].Time = &metav1.Time{Time: created}
+		t, ok = labelTime(applied, util.IstioInjectionLabel)
+		Expect(ok).To(BeTrue())
+		Expect(t).To(Equal(created))
+
+		// Without managed fields, the namespace creation is the bound.
+		ns := namespace("web")
+		ns.ManagedFields = nil
+		Expect(injectionEnabledTime(ns, nil)).To(Equal(created))
+		injector := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(labelled.Add(time.Hour))}}
+		Expect(injectionEnabledTime(namespace("web"), injector)).To(Equal(labelled.Add(time.Hour)))
+	})
+
+	It("distinguishes stale pods, injection failures and opt outs", func() {
+		ns := namespace("web")
+		stale := pod("web", "old", labelled.Add(-time.Hour))
+		failed := pod("web", "new", labelled.Add(time.Hour))
+		optedOut := pod("web", "batch", labelled.Add(time.Hour))
+		optedOut.Labels[util.IstioSidecarInjectKey] = "false"
+		done := pod("web", "job", created)
+		done.Status.Phase = corev1.PodSucceeded
+		ok := injected(pod("web", "ok", created))
+
+		var memberships []util.MeshMembership
+		for _, p := range []*corev1.Pod{stale, failed, optedOut, done, ok} {
+			memberships = append(memberships, util.PodMeshMembership(p, ns, nil))
+		}
+		// Pods in namespaces without injection aren't reported.
+		memberships = append(memberships, util.PodMeshMembership(pod("legacy", "old", created),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy"}}, nil))
+
+		injectors := map[string]*corev1.ConfigMap{util.IstioDefaultRevision: injector()}
+		notes := createNotes(memberships, map[string]*corev1.Namespace{"web": ns}, injectors)
+		Expect(notes).To(HaveLen(3))
+
+		Expect(notes[0].Type).To(Equal(predatesNoteType))
+		Expect(notes[0].Level).To(Equal(apiv1.NoteLevel_WARNING))
+		Expect(notes[0].Attr).To(Equal(map[string]string{
+			"pod_name":  stale.Name,
+			"namespace": "web",
+			"revision":  "default",
+			"owner":     "Deployment old",
+		}))
+		Expect(notes[0].Fix.GetTarget().GetName()).To(Equal("old"))
+		Expect(notes[0].Fix.GetPatch()).To(ContainSubstring(`"vet.aspenmesh.io/injection-enabled":"2021-01-02T00:00:00Z"`))
+
+		Expect(notes[1].Type).To(Equal(injectionFailedNoteType))
+		Expect(notes[1].Level).To(Equal(apiv1.NoteLevel_ERROR))
+		Expect(notes[1].Attr["owner"]).To(Equal("Deployment new"))
+
+		Expect(notes[2].Type).To(Equal(optedOutNoteType))
+		Expect(notes[2].Level).To(Equal(apiv1.NoteLevel_INFO))
+		Expect(notes[2].Attr["reason"]).To(Equal("pod label sidecar.istio.io/inject=false"))
+		Expect(notes[2].Fix).To(BeNil())
+
+		for _, n := range notes {
+			Expect(n.Id).NotTo(BeEmpty())
+			Expect(n.Fingerprint).NotTo(BeEmpty())
+		}
+
+		By("skipping pods of revisions without injector")
+		Expect(createNotes(memberships, map[string]*corev1.Namespace{"web": ns}, nil)).To(BeEmpty())
+	})
+
+	It("doesn't report injection failures when injection times are unknown", func() {
+		// Like namespaces assumed in scope or loaded from manifests
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Labels: map[string]string{util.IstioInjectionLabel: "enabled"}}}
+		cm := injector()
+		cm.CreationTimestamp = metav1.Time{}
+		optedOut := pod("web", "batch", labelled)
+		optedOut.Labels[util.IstioSidecarInjectKey] = "false"
+		memberships := []util.MeshMembership{
+			util.PodMeshMembership(pod("web", "new", labelled), ns, nil),
+			util.PodMeshMembership(optedOut, ns, nil),
+		}
+		notes := createNotes(memberships, map[string]*corev1.Namespace{"web": ns},
+			map[string]*corev1.ConfigMap{util.IstioDefaultRevision: cm})
+		Expect(notes).To(HaveLen(1))
+		Expect(notes[0].Type).To(Equal(optedOutNoteType))
+
+		// Pods loaded from manifests have no creation time either
+		memberships = []util.MeshMembership{
+			util.PodMeshMembership(pod("web", "new", time.Time{}), namespace("web"), nil)}
+		notes = createNotes(memberships, map[string]*corev1.Namespace{"web": namespace("web")},
+			map[string]*corev1.ConfigMap{util.IstioDefaultRevision: injector()})
+		Expect(notes).To(BeEmpty())
+	})
+
+	It("vets the pods from listers", func() {
+		nsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		cmIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(nsIndexer.Add(namespace("web"))).To(Succeed())
+		Expect(podIndexer.Add(pod("web", "old", created))).To(Succeed())
+		Expect(podIndexer.Add(injected(pod("web", "ok", created)))).To(Succeed())
+		Expect(cmIndexer.Add(injector())).To(Succeed())
+
+		v := NewVetterFromListers(v1.NewNamespaceLister(nsIndexer), v1.NewPodLister(podIndexer),
+			v1.NewConfigMapLister(cmIndexer), util.Environment{})
+		notes, err := v.Vet()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(HaveLen(1))
+		Expect(notes[0].Type).To(Equal(predatesNoteType))
+		Expect(notes[0].Code).To(Equal("IV0801"))
+	})
+})
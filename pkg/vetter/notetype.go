@@ -0,0 +1,68 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// docsBaseURL is where the vetter packages, along with the documentation of
+// their note types, are browsable online.
+const docsBaseURL = "https://github.com/aspenmesh/istio-vet/blob/main/pkg/vetter/"
+
+// NoteType documents a type of note generated by a vetter.
+type NoteType struct {
+	// Type of the notes, e.g. "missing-service-port-prefix"
+	Type string
+
+	// Code is a stable identifier of the note type, e.g. "IV0401". Codes
+	// are never reused once assigned.
+	Code string
+
+	// Vetter is the id of the vetter generating the notes
+	Vetter string
+
+	// Remediation is a short description of how to resolve the notes
+	Remediation string
+
+	// DocsURL is the URL of the documentation of the note type
+	DocsURL string
+
+	// Doc is the long form explanation of the note type in markdown
+	Doc string
+}
+
+// DocsURL returns the URL of the README-<noteType>.md documenting a note
+// type in the directory of a vetter package.
+func DocsURL(pkg, noteType string) string {
+	return docsBaseURL + pkg + "/README-" + noteType + ".md"
+}
+
+// Annotate sets the code, remediation and docs URL of notes from the matching
+// entry of types.
+func Annotate(notes []*apiv1.Note, types []*NoteType) {
+	for _, n := range notes {
+		for _, t := range types {
+			if n.GetType() == t.Type {
+				n.Code = t.Code
+				n.Remediation = t.Remediation
+				n.DocsUrl = t.DocsURL
+				break
+			}
+		}
+	}
+}
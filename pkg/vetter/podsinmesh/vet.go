@@ -19,6 +19,7 @@ limitations under the License.
 package podsinmesh
 
 import (
+	_ "embed"
 	"strconv"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
@@ -30,6 +31,7 @@ import (
 )
 
 const (
+	vetterID               = "podsinmesh"
 	userPodCountNoteType   = "user-pod-count"
 	userPodCountSummary    = "User pod count"
 	userPodCountMsg        = "${user_pods_in_mesh} user pods in mesh out of ${num_user_pods}"
@@ -38,10 +40,70 @@ const (
 	systemPodCountMsg      = "${num_system_pods} system pods out of mesh"
 )
 
+//go:embed README-user-pod-count.md
+var userPodCountDoc string
+
+//go:embed README-system-pod-count.md
+var systemPodCountDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        userPodCountNoteType,
+		Code:        "IV0101",
+		Vetter:      vetterID,
+		Remediation: "Informational, no action needed. Label namespaces with istio-injection=enabled to add their pods to the mesh.",
+		DocsURL:     vetter.DocsURL("podsinmesh", userPodCountNoteType),
+		Doc:         userPodCountDoc,
+	},
+	{
+		Type:        systemPodCountNoteType,
+		Code:        "IV0102",
+		Vetter:      vetterID,
+		Remediation: "Informational, no action needed.",
+		DocsURL:     vetter.DocsURL("podsinmesh", systemPodCountNoteType),
+		Doc:         systemPodCountDoc,
+	},
+}
+
 // MeshStats implements Vetter interface
 type MeshStats struct {
 	podLister v1.PodLister
 	nsLister  v1.NamespaceLister
+	env       util.Environment
+	// exempted holds the names of the namespaces counted as system
+	// namespaces, nil for the default ones
+	exempted map[string]bool
+}
+
+// Config is the config of the vetter.
+type Config struct {
+	// SystemNamespaces whose pods are counted as system pods, defaults to
+	// "kube-system", "kube-public" and the Istio namespace
+	SystemNamespaces []string `json:"systemNamespaces"`
+}
+
+// Configure implements the vetter.Configurable interface
+func (m *MeshStats) Configure(config map[string]interface{}) error {
+	var c Config
+	if err := vetter.DecodeConfig(config, &c); err != nil {
+		return err
+	}
+	if c.SystemNamespaces != nil {
+		m.exempted = map[string]bool{}
+		for _, ns := range c.SystemNamespaces {
+			m.exempted[ns] = true
+		}
+	}
+	return nil
+}
+
+// systemNamespace returns true if pods in the namespace are system pods.
+func (m *MeshStats) systemNamespace(ns string) bool {
+	if m.exempted == nil {
+		return m.env.ExemptedNamespace(ns)
+	}
+	return m.exempted[ns]
 }
 
 // Vet returns the list of generated notes
@@ -58,7 +120,7 @@ func (m *MeshStats) Vet() ([]*apiv1.Note, error) {
 			glog.Errorf("Failed to retrieve pods for namespace: %s : %s", n.Name, err)
 			return nil, err
 		}
-		if util.ExemptedNamespace(n.Name) == false {
+		if m.systemNamespace(n.Name) == false {
 			totalUserPods += len(podList)
 			for _, p := range podList {
 				if util.SidecarInjected(p) == true {
@@ -88,15 +150,30 @@ func (m *MeshStats) Vet() ([]*apiv1.Note, error) {
 				"num_system_pods": strconv.Itoa(totalSystemPods)}}}
 
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
 // Info returns information about the vetter
 func (m *MeshStats) Info() *apiv1.Info {
-	return &apiv1.Info{Id: "podsinmesh", Version: "0.1.0"}
+	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
+}
+
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Reports the number of user pods in and out of the mesh, and of system pods.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Pods},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
 }
 
 // NewVetter returns "meshStats" which implements Vetter Interface
@@ -104,6 +181,7 @@ func NewVetter(factory vetter.ResourceListGetter) *MeshStats {
 	return &MeshStats{
 		podLister: factory.K8s().Core().V1().Pods().Lister(),
 		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
+		env:       vetter.Environment(factory),
 	}
 }
 
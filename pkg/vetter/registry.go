@@ -0,0 +1,229 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// Registration describes a vetter registered with Register.
+type Registration struct {
+	// ID of the vetter, as returned in its Info
+	ID string
+
+	// Description is a one line description of what the vetter inspects
+	Description string
+
+	// DefaultEnabled is true if the vetter runs unless disabled
+	DefaultEnabled bool
+
+	// Resources lists the resources the vetter lists, e.g. Pods. Changes
+	// to these trigger re-running the vetter in watch mode.
+	Resources []string
+
+	// NoteTypes documents the types of notes generated by the vetter
+	NoteTypes []*NoteType
+
+	// New returns the vetter. It registers the informers the vetter needs,
+	// so it must be called before the factory is started.
+	New func(factory ResourceListGetter) Vetter
+}
+
+var registry = struct {
+	sync.Mutex
+	byID map[string]*Registration
+}{byID: map[string]*Registration{}}
+
+// Register registers a vetter. Vetter packages call it from an init
+// function, it panics if the ID is empty or already registered.
+func Register(r Registration) {
+	if r.ID == "" || r.New == nil {
+		panic("vetter: Register called without ID or New")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.byID[r.ID]; ok {
+		panic(fmt.Sprintf("vetter: Register called twice for %s", r.ID))
+	}
+	registry.byID[r.ID] = &r
+}
+
+// Registrations returns the registered vetters sorted by their ID, compared
+// case insensitively. Registration order depends on package initialization,
+// so it isn't kept. Vetters are run and reported in this order.
+func Registrations() []*Registration {
+	registry.Lock()
+	defer registry.Unlock()
+	regs := make([]*Registration, 0, len(registry.byID))
+	for _, r := range registry.byID {
+		regs = append(regs, r)
+	}
+	sort.Slice(regs, func(i, j int) bool {
+		return strings.ToLower(regs[i].ID) < strings.ToLower(regs[j].ID)
+	})
+	return regs
+}
+
+// Lookup returns the registration of the vetter with the given ID.
+func Lookup(id string) (*Registration, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	r, ok := registry.byID[id]
+	return r, ok
+}
+
+// NewVetters returns the registered vetters which are enabled by default,
+// with their default configuration.
+func NewVetters(factory ResourceListGetter) []Vetter {
+	vList, _ := (&Selector{}).NewVetters(factory, nil)
+	return vList
+}
+
+// Selector selects the vetters to run and the types of notes they report.
+// The zero value selects the vetters enabled by default and all note types.
+type Selector struct {
+	vetters       map[string]bool
+	disabledTypes map[string]bool
+}
+
+// NewSelector returns a Selector enabling and disabling the vetters and note
+// types named in enable and disable. Names are vetter ids or note types,
+// matched case insensitively. Enabling a note type enables its vetter.
+func NewSelector(enable, disable []string) (*Selector, error) {
+	s := &Selector{vetters: map[string]bool{}, disabledTypes: map[string]bool{}}
+	enabled := map[string]bool{}
+	for _, name := range enable {
+		enabled[strings.ToLower(name)] = true
+		r, _, err := lookupName(name)
+		if err != nil {
+			return nil, err
+		}
+		s.vetters[r.ID] = true
+	}
+	for _, name := range disable {
+		if enabled[strings.ToLower(name)] {
+			return nil, fmt.Errorf("%s is both enabled and disabled", name)
+		}
+		r, t, err := lookupName(name)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			s.disabledTypes[t.Type] = true
+		} else {
+			s.vetters[r.ID] = false
+		}
+	}
+	return s, nil
+}
+
+// lookupName returns the registration of the vetter with id name, or of the
+// vetter generating the note type name along with the note type.
+func lookupName(name string) (*Registration, *NoteType, error) {
+	for _, r := range Registrations() {
+		if strings.EqualFold(r.ID, name) {
+			return r, nil, nil
+		}
+		for _, t := range r.NoteTypes {
+			if strings.EqualFold(t.Type, name) {
+				return r, t, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("unknown vetter or note type %q", name)
+}
+
+// Enabled returns true if the vetter with the given id is selected.
+func (s *Selector) Enabled(id string) bool {
+	if enabled, ok := s.vetters[id]; ok {
+		return enabled
+	}
+	r, ok := Lookup(id)
+	return ok && r.DefaultEnabled
+}
+
+// NoteTypeEnabled returns true if notes of the given type are reported.
+func (s *Selector) NoteTypeEnabled(noteType string) bool {
+	return !s.disabledTypes[noteType]
+}
+
+// NewVetters returns the selected vetters, configured with the sections of
+// config keyed by vetter id. It fails if config is invalid. Vetters
+// generating disabled note types are wrapped to drop these notes.
+func (s *Selector) NewVetters(factory ResourceListGetter, config map[string]interface{}) ([]Vetter, error) {
+	var vList []Vetter
+	for _, r := range Registrations() {
+		if s.Enabled(r.ID) {
+			vList = append(vList, r.New(factory))
+		}
+	}
+	if err := configure(vList, config); err != nil {
+		return nil, err
+	}
+	for i, v := range vList {
+		r, _ := Lookup(v.Info().GetId())
+		for _, t := range r.NoteTypes {
+			if !s.NoteTypeEnabled(t.Type) {
+				vList[i] = &filteringVetter{Vetter: v, selector: s}
+				break
+			}
+		}
+	}
+	return vList, nil
+}
+
+type filteringVetter struct {
+	Vetter
+	selector *Selector
+}
+
+func (v *filteringVetter) Unwrap() Vetter {
+	return v.Vetter
+}
+
+func (v *filteringVetter) Vet() ([]*apiv1.Note, error) {
+	return v.VetContext(context.Background())
+}
+
+func (v *filteringVetter) VetContext(ctx context.Context) ([]*apiv1.Note, error) {
+	notes, err := VetContext(ctx, v.Vetter)
+	if err != nil {
+		return notes, err
+	}
+	filtered := []*apiv1.Note{}
+	for _, n := range notes {
+		if v.selector.NoteTypeEnabled(n.GetType()) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered, nil
+}
+
+// NoteTypes returns the note types of all registered vetters.
+func NoteTypes() []*NoteType {
+	var types []*NoteType
+	for _, r := range Registrations() {
+		types = append(types, r.NoteTypes...)
+	}
+	return types
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+	_ "github.com/aspenmesh/istio-vet/pkg/vetter/all"
+)
+
+type factory struct {
+	k8s   informers.SharedInformerFactory
+	istio istioinformer.SharedInformerFactory
+}
+
+func (f *factory) K8s() informers.SharedInformerFactory       { return f.k8s }
+func (f *factory) Istio() istioinformer.SharedInformerFactory { return f.istio }
+
+// defaultEnabled returns the number of vetters enabled by default.
+func defaultEnabled() int {
+	n := 0
+	for _, r := range vetter.Registrations() {
+		if r.DefaultEnabled {
+			n++
+		}
+	}
+	return n
+}
+
+func newFactory() *factory {
+	return &factory{
+		k8s:   informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0),
+		istio: istioinformer.NewSharedInformerFactory(istiofake.NewSimpleClientset(), 0),
+	}
+}
+
+var _ = Describe("Registry", func() {
+	It("has all vetters registered", func() {
+		var ids []string
+		for _, r := range vetter.Registrations() {
+			ids = append(ids, r.ID)
+			Expect(r.Description).NotTo(BeEmpty())
+			Expect(r.Resources).NotTo(BeEmpty())
+			Expect(r.NoteTypes).NotTo(BeEmpty())
+		}
+		Expect(ids).To(Equal([]string{
+			"AppLabel",
+			"ConflictingVirtualServiceHost",
+			"DanglingRouteDestinationHost",
+			"MeshVersion",
+			"MissingSidecar",
+			"podsinmesh",
+			"serviceassociation",
+			"serviceportprefix",
+			"WorkloadVersion",
+		}))
+	})
+
+	It("creates the vetters with their registered ids", func() {
+		f := newFactory()
+		vList := vetter.NewVetters(f)
+		Expect(vList).To(HaveLen(defaultEnabled()))
+		for _, v := range vList {
+			r, ok := vetter.Lookup(v.Info().GetId())
+			Expect(ok).To(BeTrue())
+			Expect(r.ID).To(Equal(v.Info().GetId()))
+		}
+	})
+
+	It("rejects duplicate registrations", func() {
+		Expect(func() {
+			vetter.Register(vetter.Registration{
+				ID:  "AppLabel",
+				New: func(vetter.ResourceListGetter) vetter.Vetter { return nil },
+			})
+		}).To(Panic())
+	})
+})
+
+var _ = Describe("NoteTypes", func() {
+	It("documents every note type with a unique code", func() {
+		codes := map[string]bool{}
+		for _, t := range vetter.NoteTypes() {
+			Expect(codes).NotTo(HaveKey(t.Code))
+			codes[t.Code] = true
+			Expect(t.Type).NotTo(BeEmpty())
+			Expect(t.Remediation).NotTo(BeEmpty())
+			Expect(t.Doc).NotTo(BeEmpty())
+		}
+	})
+
+	It("annotates notes of known types", func() {
+		notes := []*apiv1.Note{{Type: "missing-app-label"}, {Type: "unknown"}}
+		vetter.Annotate(notes, vetter.NoteTypes())
+		Expect(notes[0].Code).To(Equal("IV0301"))
+		Expect(notes[0].DocsUrl).To(HaveSuffix("/applabel/README-missing-app-label.md"))
+		Expect(notes[1].Code).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Selector", func() {
+	It("selects the vetters enabled by default", func() {
+		s, err := vetter.NewSelector(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Enabled("podsinmesh")).To(BeTrue())
+		Expect(s.Enabled("WorkloadVersion")).To(BeFalse())
+		Expect(s.Enabled("unknown")).To(BeFalse())
+		Expect(s.NoteTypeEnabled("init-image-mismatch")).To(BeTrue())
+	})
+
+	It("disables vetters and note types by name", func() {
+		s, err := vetter.NewSelector(nil, []string{"PodsInMesh", "init-image-mismatch"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Enabled("podsinmesh")).To(BeFalse())
+		Expect(s.Enabled("MeshVersion")).To(BeTrue())
+		Expect(s.NoteTypeEnabled("init-image-mismatch")).To(BeFalse())
+		Expect(s.NoteTypeEnabled("sidecar-image-mismatch")).To(BeTrue())
+	})
+
+	It("rejects unknown and conflicting names", func() {
+		_, err := vetter.NewSelector([]string{"foo"}, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = vetter.NewSelector([]string{"AppLabel"}, []string{"applabel"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns the enabled vetters", func() {
+		s, err := vetter.NewSelector(nil, []string{"podsinmesh", "init-image-mismatch"})
+		Expect(err).NotTo(HaveOccurred())
+		f := newFactory()
+		vList, err := s.NewVetters(f, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vList).To(HaveLen(defaultEnabled() - 1))
+		for _, v := range vList {
+			Expect(v.Info().GetId()).NotTo(Equal("podsinmesh"))
+		}
+	})
+})
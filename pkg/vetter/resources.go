@@ -0,0 +1,74 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// Names of the resources vetters list. They match the plural resource names
+// used by the Kubernetes API.
+const (
+	Pods             = "pods"
+	Services         = "services"
+	Endpoints        = "endpoints"
+	Namespaces       = "namespaces"
+	ConfigMaps       = "configmaps"
+	ReplicaSets      = "replicasets"
+	VirtualServices  = "virtualservices"
+	DestinationRules = "destinationrules"
+)
+
+var resourceInformers = map[string]func(ResourceListGetter) cache.SharedIndexInformer{
+	Pods: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.K8s().Core().V1().Pods().Informer()
+	},
+	Services: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.K8s().Core().V1().Services().Informer()
+	},
+	Endpoints: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.K8s().Core().V1().Endpoints().Informer()
+	},
+	Namespaces: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.K8s().Core().V1().Namespaces().Informer()
+	},
+	ConfigMaps: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.K8s().Core().V1().ConfigMaps().Informer()
+	},
+	ReplicaSets: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.K8s().Apps().V1().ReplicaSets().Informer()
+	},
+	VirtualServices: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.Istio().Networking().V1beta1().VirtualServices().Informer()
+	},
+	DestinationRules: func(f ResourceListGetter) cache.SharedIndexInformer {
+		return f.Istio().Networking().V1beta1().DestinationRules().Informer()
+	},
+}
+
+// Informer returns the shared informer for the named resource. It is meant
+// for tools driving vetters, e.g. to watch for changes; vetters themselves
+// should only use listers.
+func Informer(f ResourceListGetter, resource string) (cache.SharedIndexInformer, error) {
+	i, ok := resourceInformers[resource]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q", resource)
+	}
+	return i(f), nil
+}
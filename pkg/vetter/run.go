@@ -0,0 +1,176 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// RunOptions controls how RunAll runs vetters.
+type RunOptions struct {
+	// Workers is the number of vetters run concurrently, the number of CPUs
+	// if zero
+	Workers int
+
+	// Timeout is the time a vetter may run for, unlimited if zero
+	Timeout time.Duration
+
+	// Orphans tracks the vetters which timed out and didn't return yet, to
+	// not run them again until they do. It is shared by everything running
+	// the same vetters, timed out vetters are run again right away if nil
+	Orphans *Orphans
+}
+
+// Result holds the notes generated by a vetter or the error it failed with.
+type Result struct {
+	Notes []*apiv1.Note
+	Err   error
+}
+
+// VetContext runs v with ctx if it is a ContextVetter, or else with Vet. A
+// panic of v is returned as an error. Vetters wrapping another vetter use it
+// to run the wrapped vetter.
+func VetContext(ctx context.Context, v Vetter) (notes []*apiv1.Note, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			glog.Errorf("Vetter %s panicked: %v\n%s", v.Info().GetId(), p, debug.Stack())
+			notes, err = nil, fmt.Errorf("vetter panicked: %v", p)
+		}
+	}()
+	if cv, ok := v.(ContextVetter); ok {
+		return cv.VetContext(ctx)
+	}
+	return v.Vet()
+}
+
+// Orphans tracks the vetters whose run timed out but which didn't return yet,
+// to not run them again until they do. Its zero value is ready to use.
+type Orphans struct {
+	mu      sync.Mutex
+	vetters map[Vetter]bool
+}
+
+// running returns whether a run of v timed out and didn't return yet.
+func (o *Orphans) running(v Vetter) bool {
+	if o == nil {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.vetters[v]
+}
+
+// add tracks v until finished is closed.
+func (o *Orphans) add(v Vetter, finished <-chan struct{}) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	if o.vetters == nil {
+		o.vetters = map[Vetter]bool{}
+	}
+	o.vetters[v] = true
+	o.mu.Unlock()
+	go func() {
+		<-finished
+		o.mu.Lock()
+		delete(o.vetters, v)
+		o.mu.Unlock()
+	}()
+}
+
+// errStillRunning is returned when running a vetter whose previous run timed
+// out but didn't return yet.
+var errStillRunning = errors.New("vetter is still running since a previous run timed out")
+
+// Run runs v with VetContext and fails once ctx is done or v ran for longer
+// than timeout, unless it is zero. A vetter which is not a ContextVetter keeps
+// running until Vet returns, its notes are dropped. The context passed to v
+// is done once Run returns, so vetters wrapping another vetter can skip
+// their side effects once the run failed.
+func Run(ctx context.Context, v Vetter, timeout time.Duration) ([]*apiv1.Note, error) {
+	r := run(ctx, v, timeout, nil)
+	return r.Notes, r.Err
+}
+
+// run runs v like Run, unless a previous run of v tracked by orphans didn't
+// return yet. If v times out, it is tracked by orphans until it returns.
+func run(ctx context.Context, v Vetter, timeout time.Duration, orphans *Orphans) Result {
+	if orphans.running(v) {
+		return Result{Err: errStillRunning}
+	}
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	done := make(chan Result, 1)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		notes, err := VetContext(ctx, v)
+		done <- Result{Notes: notes, Err: err}
+	}()
+	select {
+	case r := <-done:
+		return r
+	case <-ctx.Done():
+		orphans.add(v, finished)
+		if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+			return Result{Err: fmt.Errorf("vetter timed out after %s", timeout)}
+		}
+		return Result{Err: ctx.Err()}
+	}
+}
+
+// RunAll runs the vetters in vList with Run, up to opts.Workers at a time,
+// and returns their results in the order of vList. The worker of a vetter
+// which timed out is freed right away, the vetter keeps running in the
+// background and fails without running while it does if opts.Orphans
+// tracks it.
+func RunAll(ctx context.Context, vList []Vetter, opts RunOptions) []Result {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]Result, len(vList))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, v := range vList {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, v Vetter) {
+			defer wg.Done()
+			results[i] = run(ctx, v, opts.Timeout, opts.Orphans)
+			<-sem
+		}(i, v)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter_test
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+// funcVetter runs vet, counting the vetters running concurrently.
+type funcVetter struct {
+	id      string
+	vet     func() ([]*apiv1.Note, error)
+	running *int32
+	max     *int32
+}
+
+func (v *funcVetter) Vet() ([]*apiv1.Note, error) {
+	if v.running != nil {
+		n := atomic.AddInt32(v.running, 1)
+		defer atomic.AddInt32(v.running, -1)
+		for {
+			m := atomic.LoadInt32(v.max)
+			if n <= m || atomic.CompareAndSwapInt32(v.max, m, n) {
+				break
+			}
+		}
+	}
+	return v.vet()
+}
+
+func (v *funcVetter) Info() *apiv1.Info {
+	return &apiv1.Info{Id: v.id, Version: "0.1.0"}
+}
+
+// contextVetter blocks until its context is done.
+type contextVetter struct {
+	funcVetter
+}
+
+func (v *contextVetter) VetContext(ctx context.Context) ([]*apiv1.Note, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func notes(id string) func() ([]*apiv1.Note, error) {
+	return func() ([]*apiv1.Note, error) {
+		time.Sleep(10 * time.Millisecond)
+		return []*apiv1.Note{&apiv1.Note{Id: id}}, nil
+	}
+}
+
+var _ = Describe("Run", func() {
+	It("returns the results in order with a bounded number of workers", func() {
+		var running, max int32
+		var vList []vetter.Vetter
+		for _, id := range []string{"a", "b", "c", "d", "e"} {
+			vList = append(vList, &funcVetter{id: id, vet: notes(id), running: &running, max: &max})
+		}
+		results := vetter.RunAll(context.Background(), vList, vetter.RunOptions{Workers: 2})
+		Expect(results).To(HaveLen(5))
+		for i, id := range []string{"a", "b", "c", "d", "e"} {
+			Expect(results[i].Err).NotTo(HaveOccurred())
+			Expect(results[i].Notes[0].GetId()).To(Equal(id))
+		}
+		Expect(max).To(BeNumerically("<=", 2))
+	})
+
+	It("returns panics as errors", func() {
+		v := &funcVetter{id: "a", vet: func() ([]*apiv1.Note, error) { panic("boom") }}
+		results := vetter.RunAll(context.Background(), []vetter.Vetter{v, &funcVetter{id: "b", vet: notes("b")}},
+			vetter.RunOptions{})
+		Expect(results[0].Err).To(MatchError("vetter panicked: boom"))
+		Expect(results[1].Err).NotTo(HaveOccurred())
+		Expect(results[1].Notes).To(HaveLen(1))
+	})
+
+	It("fails vetters which time out", func() {
+		block := make(chan struct{})
+		defer close(block)
+		v := &funcVetter{id: "a", vet: func() ([]*apiv1.Note, error) {
+			<-block
+			return nil, nil
+		}}
+		_, err := vetter.Run(context.Background(), v, 10*time.Millisecond)
+		Expect(err).To(MatchError("vetter timed out after 10ms"))
+	})
+
+	It("frees the worker of vetters which time out", func() {
+		block := make(chan struct{})
+		defer close(block)
+		stuck := &funcVetter{id: "a", vet: func() ([]*apiv1.Note, error) {
+			<-block
+			return nil, nil
+		}}
+		done := make(chan []vetter.Result)
+		go func() {
+			done <- vetter.RunAll(context.Background(), []vetter.Vetter{stuck, &funcVetter{id: "b", vet: notes("b")}},
+				vetter.RunOptions{Workers: 1, Timeout: 100 * time.Millisecond})
+		}()
+		var results []vetter.Result
+		Eventually(done).Should(Receive(&results))
+		Expect(results[0].Err).To(MatchError("vetter timed out after 100ms"))
+		Expect(results[1].Err).NotTo(HaveOccurred())
+	})
+
+	It("doesn't run vetters again before a run which timed out returns", func() {
+		block := make(chan struct{})
+		v := &funcVetter{id: "a", vet: func() ([]*apiv1.Note, error) {
+			<-block
+			return nil, nil
+		}}
+		opts := vetter.RunOptions{Timeout: 10 * time.Millisecond, Orphans: &vetter.Orphans{}}
+		results := vetter.RunAll(context.Background(), []vetter.Vetter{v}, opts)
+		Expect(results[0].Err).To(MatchError("vetter timed out after 10ms"))
+		results = vetter.RunAll(context.Background(), []vetter.Vetter{v}, opts)
+		Expect(results[0].Err).To(MatchError("vetter is still running since a previous run timed out"))
+		close(block)
+		Eventually(func() error {
+			return vetter.RunAll(context.Background(), []vetter.Vetter{v}, opts)[0].Err
+		}).Should(Succeed())
+	})
+
+	It("runs the same vetter concurrently", func() {
+		opts := vetter.RunOptions{Timeout: time.Second, Orphans: &vetter.Orphans{}}
+		v := &funcVetter{id: "a", vet: notes("a")}
+		done := make(chan []vetter.Result, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				done <- vetter.RunAll(context.Background(), []vetter.Vetter{v, v}, opts)
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			var results []vetter.Result
+			Eventually(done).Should(Receive(&results))
+			for _, r := range results {
+				Expect(r.Err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	It("cancels context vetters", func() {
+		v := &contextVetter{funcVetter{id: "a"}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		notes, err := vetter.VetContext(ctx, v)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(notes).To(BeEmpty())
+
+		_, err = vetter.Run(context.Background(), v, 10*time.Millisecond)
+		Expect(err).To(MatchError("vetter timed out after 10ms"))
+	})
+})
@@ -0,0 +1,43 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+type fakeVetter struct {
+	notes []*apiv1.Note
+}
+
+func (v *fakeVetter) Vet() ([]*apiv1.Note, error) { return v.notes, nil }
+func (v *fakeVetter) Info() *apiv1.Info           { return &apiv1.Info{Id: "fake"} }
+
+var _ = Describe("filteringVetter", func() {
+	It("drops the notes of disabled types", func() {
+		s := &Selector{disabledTypes: map[string]bool{"init-image-mismatch": true}}
+		v := &filteringVetter{Vetter: &fakeVetter{notes: []*apiv1.Note{
+			{Type: "init-image-mismatch"}, {Type: "sidecar-image-mismatch"}}}, selector: s}
+		notes, err := v.Vet()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(HaveLen(1))
+		Expect(notes[0].GetType()).To(Equal("sidecar-image-mismatch"))
+	})
+})
@@ -19,6 +19,7 @@ limitations under the License.
 package serviceassociation
 
 import (
+	_ "embed"
 	"fmt"
 	"strings"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/aspenmesh/istio-vet/pkg/vetter"
 	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	v1 "k8s.io/client-go/listers/core/v1"
 )
 
@@ -38,6 +40,21 @@ const (
 		" service definitions ensuring the pod belongs to a single service."
 )
 
+//go:embed README-multiple-service-association.md
+var multipleServiceAssociationDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        multipleServiceAssociationNoteType,
+		Code:        "IV0501",
+		Vetter:      vetterID,
+		Remediation: "Update the selectors of the services so that every pod belongs to a single service.",
+		DocsURL:     vetter.DocsURL("serviceassociation", multipleServiceAssociationNoteType),
+		Doc:         multipleServiceAssociationDoc,
+	},
+}
+
 // SvcAssociation implements Vetter interface
 type SvcAssociation struct {
 	nsLister  v1.NamespaceLister
@@ -48,6 +65,7 @@ type SvcAssociation struct {
 type endpointInfo struct {
 	Namespace    string
 	PodName      string
+	PodUID       types.UID
 	ServiceNames []string
 }
 
@@ -63,6 +81,7 @@ func createEndpointMap(e []*corev1.Endpoints, podLister v1.PodLister) map[string
 							endpointMap[epMapKey] = endpointInfo{
 								Namespace:    ep.Namespace,
 								PodName:      a.TargetRef.Name,
+								PodUID:       a.TargetRef.UID,
 								ServiceNames: []string{ep.Name}}
 						} else {
 							svcs := append(epInfo.ServiceNames, ep.Name)
@@ -93,6 +112,12 @@ func (m *SvcAssociation) Vet() ([]*apiv1.Note, error) {
 	epMap := createEndpointMap(endpoints, m.podLister)
 	for _, v := range epMap {
 		if len(v.ServiceNames) > 1 {
+			refs := []*apiv1.ObjectReference{
+				util.ObjectRef(util.PodKind, v.Namespace, v.PodName, v.PodUID)}
+			for _, s := range v.ServiceNames {
+				// Endpoints are named after their service.
+				refs = append(refs, util.ObjectRef(util.ServiceKind, v.Namespace, s, ""))
+			}
 			notes = append(notes, &apiv1.Note{
 				Type:    multipleServiceAssociationNoteType,
 				Summary: multipleServiceAssociationSummary,
@@ -101,14 +126,17 @@ func (m *SvcAssociation) Vet() ([]*apiv1.Note, error) {
 				Attr: map[string]string{
 					"pod_name":     v.PodName,
 					"namespace":    v.Namespace,
-					"service_list": strings.Join(v.ServiceNames, ", ")}})
+					"service_list": strings.Join(v.ServiceNames, ", ")},
+				Refs: refs})
 		}
 	}
 
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
@@ -117,6 +145,19 @@ func (m *SvcAssociation) Info() *apiv1.Info {
 	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
 }
 
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes if a pod in the mesh is associated with multiple services.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Endpoints, vetter.Pods},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
 // NewVetter returns "svcAssociation" which implements Vetter Interface
 func NewVetter(factory vetter.ResourceListGetter) *SvcAssociation {
 	return &SvcAssociation{
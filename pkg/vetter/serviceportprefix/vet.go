@@ -19,11 +19,14 @@ limitations under the License.
 package serviceportprefix
 
 import (
+	_ "embed"
+	"fmt"
 	"strings"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
 	"github.com/aspenmesh/istio-vet/pkg/vetter"
 	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/client-go/listers/core/v1"
 )
 
@@ -37,10 +40,103 @@ const (
 		" Consider updating the service port name with one of the mesh recognized prefixes."
 )
 
+//go:embed README-missing-service-port-prefix.md
+var missingServicePortPrefixDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        servicePortPrefixNoteType,
+		Code:        "IV0401",
+		Vetter:      vetterID,
+		Remediation: "Rename the service ports to <protocol>-<suffix>, e.g. \"http-web\", or use the \"tcp-\" prefix to opt out of layer 7 features.",
+		DocsURL:     vetter.DocsURL("serviceportprefix", servicePortPrefixNoteType),
+		Doc:         missingServicePortPrefixDoc,
+	},
+}
+
+// wellKnownPorts maps ports to the protocol prefix suggested for them, other
+// ports are suggested to be prefixed with "tcp".
+var wellKnownPorts = map[int32]string{
+	80:    "http",
+	443:   "https",
+	6379:  "redis",
+	8080:  "http",
+	8443:  "https",
+	27017: "mongo",
+}
+
+// prefixedPortName returns the name suggested for port p, given the
+// recognized protocols.
+func prefixedPortName(p corev1.ServicePort, protocols []string) string {
+	prefix, ok := wellKnownPorts[p.Port]
+	if !ok || !util.ServicePortPrefixedWith(prefix, protocols) {
+		prefix = "tcp"
+	}
+	if p.Name == "" {
+		return prefix
+	}
+	return prefix + "-" + p.Name
+}
+
+// portPrefixFix returns a Fix renaming the ports of s at the indexes
+// renamed to their prefixed names. Strategic merge patches merge Service
+// ports by port number only, which mixes up a TCP and a UDP port with the
+// same number, so the patch replaces the whole list of ports instead.
+func portPrefixFix(s *corev1.Service, renamed []int, protocols []string) *apiv1.Fix {
+	var renames []string
+	ports := append([]corev1.ServicePort{}, s.Spec.Ports...)
+	for _, i := range renamed {
+		name := prefixedPortName(ports[i], protocols)
+		renames = append(renames, fmt.Sprintf("%d to %q", ports[i].Port, name))
+		ports[i].Name = name
+	}
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{"ports": ports},
+	}
+	return util.NewFix("Rename ports "+strings.Join(renames, ", ")+" of Service "+s.Name,
+		util.ObjectRef(util.ServiceKind, s.Namespace, s.Name, s.UID),
+		apiv1.PatchType_JSON_MERGE_PATCH, patch)
+}
+
+// Config is the config of the vetter.
+type Config struct {
+	// Protocols recognized as port name prefixes, defaults to the
+	// protocols recognized by Istio
+	Protocols []string `json:"protocols"`
+
+	// Level of the notes, defaults to "warning"
+	Level string `json:"level"`
+}
+
 // SvcPortPrefix implements Vetter interface
 type SvcPortPrefix struct {
 	nsLister  v1.NamespaceLister
 	svcLister v1.ServiceLister
+	protocols []string
+	level     apiv1.NoteLevel
+}
+
+// Configure implements the vetter.Configurable interface
+func (m *SvcPortPrefix) Configure(config map[string]interface{}) error {
+	var c Config
+	if err := vetter.DecodeConfig(config, &c); err != nil {
+		return err
+	}
+	if c.Protocols != nil {
+		if len(c.Protocols) == 0 {
+			return fmt.Errorf("protocols must not be empty")
+		}
+		m.protocols = c.Protocols
+	}
+	if c.Level != "" {
+		l, err := vetter.ParseLevel(c.Level)
+		if err != nil {
+			return err
+		}
+		m.level = l
+	}
+	return nil
 }
 
 // Vet returns the list of generated notes
@@ -57,10 +153,12 @@ func (m *SvcPortPrefix) Vet() ([]*apiv1.Note, error) {
 	}
 	for _, s := range services {
 		var unsupportedPortPrefixes []string
-		for _, p := range s.Spec.Ports {
+		var unsupportedPorts []int
+		for i, p := range s.Spec.Ports {
 			if p.Protocol != util.ServiceProtocolUDP &&
-				util.ServicePortPrefixed(p.Name) == false {
+				util.ServicePortPrefixedWith(p.Name, m.protocols) == false {
 				unsupportedPortPrefixes = append(unsupportedPortPrefixes, p.Name)
+				unsupportedPorts = append(unsupportedPorts, i)
 			}
 		}
 		if len(unsupportedPortPrefixes) > 0 {
@@ -68,18 +166,23 @@ func (m *SvcPortPrefix) Vet() ([]*apiv1.Note, error) {
 				Type:    servicePortPrefixNoteType,
 				Summary: servicePortPrefixSummary,
 				Msg:     servicePortPrefixMsg,
-				Level:   apiv1.NoteLevel_WARNING,
+				Level:   m.level,
 				Attr: map[string]string{
 					"service_name":  s.Name,
 					"namespace":     s.Namespace,
-					"port_prefixes": strings.Join(unsupportedPortPrefixes, ", ")}})
+					"port_prefixes": strings.Join(unsupportedPortPrefixes, ", ")},
+				Refs: []*apiv1.ObjectReference{
+					util.ObjectRef(util.ServiceKind, s.Namespace, s.Name, s.UID)},
+				Fix: portPrefixFix(s, unsupportedPorts, m.protocols)})
 		}
 	}
 
 	for i := range notes {
-		notes[i].Id = util.ComputeID(notes[i])
+		notes[i].Id = util.ComputeID(vetterID, notes[i])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
 	}
 
+	vetter.Annotate(notes, NoteTypes)
 	return notes, nil
 }
 
@@ -88,11 +191,26 @@ func (m *SvcPortPrefix) Info() *apiv1.Info {
 	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
 }
 
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes if service port names are missing Istio recognized protocol prefixes.",
+		DefaultEnabled: true,
+		Resources:      []string{vetter.Namespaces, vetter.Services},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
 // NewVetter returns "svcPortPrefix" which implements Vetter Interface
 func NewVetter(factory vetter.ResourceListGetter) *SvcPortPrefix {
 	return &SvcPortPrefix{
 		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
 		svcLister: factory.K8s().Core().V1().Services().Lister(),
+		protocols: util.ServicePortProtocols(),
+		level:     apiv1.NoteLevel_WARNING,
 	}
 }
 
@@ -100,5 +218,7 @@ func NewVetterFromListers(nsLister v1.NamespaceLister, svcLister v1.ServiceListe
 	return &SvcPortPrefix{
 		nsLister:  nsLister,
 		svcLister: svcLister,
+		protocols: util.ServicePortProtocols(),
+		level:     apiv1.NoteLevel_WARNING,
 	}
 }
@@ -0,0 +1,200 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	istioNetListers "istio.io/client-go/pkg/listers/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// IgnoreAnnotation on a Pod, Service, VirtualService or Namespace lists the
+// note types or codes, separated by commas, to suppress for the object or
+// for all objects in the namespace. "*" suppresses all notes.
+const IgnoreAnnotation = "vet.aspenmesh.io/ignore"
+
+// Unwrapper is implemented by vetters wrapping another vetter, e.g. to
+// instrument its runs.
+type Unwrapper interface {
+	// Unwrap returns the wrapped vetter.
+	Unwrap() Vetter
+}
+
+// Suppressed returns the number of notes suppressed in the last run of v,
+// unwrapping v until a vetter wrapped by a Suppressor is found. It is zero
+// if the last run failed or is still running.
+func Suppressed(v Vetter) int {
+	for v != nil {
+		if s, ok := v.(*suppressingVetter); ok {
+			return s.suppressor.count(s.Info().GetId())
+		}
+		u, ok := v.(Unwrapper)
+		if !ok {
+			return 0
+		}
+		v = u.Unwrap()
+	}
+	return 0
+}
+
+// Suppressor drops the notes on objects annotated with IgnoreAnnotation.
+type Suppressor struct {
+	nsLister  v1.NamespaceLister
+	podLister v1.PodLister
+	svcLister v1.ServiceLister
+	vsLister  istioNetListers.VirtualServiceLister
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewSuppressor returns a Suppressor listing annotated objects from
+// factory. It registers the informers it needs, so it must be called before
+// the factory is started.
+func NewSuppressor(factory ResourceListGetter) *Suppressor {
+	return &Suppressor{
+		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
+		podLister: factory.K8s().Core().V1().Pods().Lister(),
+		svcLister: factory.K8s().Core().V1().Services().Lister(),
+		vsLister:  factory.Istio().Networking().V1beta1().VirtualServices().Lister(),
+		counts:    map[string]int{},
+	}
+}
+
+// Instrument returns vList with every vetter wrapped to drop suppressed
+// notes. It should wrap the vetters before anything consuming their notes.
+func (s *Suppressor) Instrument(vList []Vetter) []Vetter {
+	out := make([]Vetter, len(vList))
+	for i, v := range vList {
+		out[i] = &suppressingVetter{Vetter: v, suppressor: s}
+	}
+	return out
+}
+
+type suppressingVetter struct {
+	Vetter
+	suppressor *Suppressor
+}
+
+func (v *suppressingVetter) Unwrap() Vetter {
+	return v.Vetter
+}
+
+func (v *suppressingVetter) Vet() ([]*apiv1.Note, error) {
+	return v.VetContext(context.Background())
+}
+
+func (v *suppressingVetter) VetContext(ctx context.Context) ([]*apiv1.Note, error) {
+	// Don't report the count of the previous run if this one fails.
+	v.suppressor.setCount(v.Info().GetId(), 0)
+	notes, err := VetContext(ctx, v.Vetter)
+	if err != nil {
+		return notes, err
+	}
+	if ctx.Err() != nil {
+		// The run already failed, don't record its count.
+		return nil, ctx.Err()
+	}
+	kept := []*apiv1.Note{}
+	for _, n := range notes {
+		if !v.suppressor.Suppress(n) {
+			kept = append(kept, n)
+		}
+	}
+	v.suppressor.setCount(v.Info().GetId(), len(notes)-len(kept))
+	return kept, nil
+}
+
+func (s *Suppressor) setCount(id string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[id] = n
+}
+
+func (s *Suppressor) count(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[id]
+}
+
+// Suppress returns true if n refers to an object, or to a namespace holding
+// an object, annotated to ignore the type or code of n.
+func (s *Suppressor) Suppress(n *apiv1.Note) bool {
+	namespaces := map[string]bool{}
+	if ns := n.GetAttr()["namespace"]; ns != "" {
+		namespaces[ns] = true
+	}
+	for _, r := range n.GetRefs() {
+		if r.GetNamespace() != "" {
+			namespaces[r.GetNamespace()] = true
+		}
+		if obj := s.object(r); obj != nil && ignores(obj, n) {
+			return true
+		}
+	}
+	for ns := range namespaces {
+		if obj, err := s.nsLister.Get(ns); err == nil && ignores(obj, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// object returns the object r refers to, or nil if it doesn't exist or isn't
+// of a kind which can be annotated to suppress notes.
+func (s *Suppressor) object(r *apiv1.ObjectReference) metav1.Object {
+	var obj metav1.Object
+	var err error
+	switch {
+	case r.GetGroup() == "" && r.GetKind() == "Pod":
+		obj, err = s.podLister.Pods(r.GetNamespace()).Get(r.GetName())
+	case r.GetGroup() == "" && r.GetKind() == "Service":
+		obj, err = s.svcLister.Services(r.GetNamespace()).Get(r.GetName())
+	case r.GetGroup() == "" && r.GetKind() == "Namespace":
+		obj, err = s.nsLister.Get(r.GetName())
+	case r.GetGroup() == "networking.istio.io" && r.GetKind() == "VirtualService":
+		obj, err = s.vsLister.VirtualServices(r.GetNamespace()).Get(r.GetName())
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+// ignores returns true if the IgnoreAnnotation of obj lists the type or
+// code of n.
+func ignores(obj metav1.Object, n *apiv1.Note) bool {
+	value, ok := obj.GetAnnotations()[IgnoreAnnotation]
+	if !ok {
+		return false
+	}
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || t == n.GetType() || (n.GetCode() != "" && strings.EqualFold(t, n.GetCode())) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vetter_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	istionetv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+)
+
+func meta(namespace, name, ignore string) metav1.ObjectMeta {
+	m := metav1.ObjectMeta{Namespace: namespace, Name: name}
+	if ignore != "" {
+		m.Annotations = map[string]string{vetter.IgnoreAnnotation: ignore}
+	}
+	return m
+}
+
+func ref(group, kind, namespace, name string) *apiv1.ObjectReference {
+	return &apiv1.ObjectReference{Group: group, Kind: kind, Namespace: namespace, Name: name}
+}
+
+type notesVetter struct {
+	notes []*apiv1.Note
+}
+
+func (v *notesVetter) Vet() ([]*apiv1.Note, error) { return v.notes, nil }
+func (v *notesVetter) Info() *apiv1.Info           { return &apiv1.Info{Id: "notes"} }
+
+// failingVetter returns its notes, or err if set.
+type failingVetter struct {
+	notesVetter
+	err error
+}
+
+func (v *failingVetter) Vet() ([]*apiv1.Note, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return v.notes, nil
+}
+
+// wrapper wraps a vetter like the metrics of a vet process.
+type wrapper struct {
+	vetter.Vetter
+}
+
+func (w *wrapper) Unwrap() vetter.Vetter { return w.Vetter }
+
+var _ = Describe("Suppressor", func() {
+	var s *vetter.Suppressor
+
+	BeforeEach(func() {
+		k8sClient := fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: meta("", "quiet", "*")},
+			&corev1.Namespace{ObjectMeta: meta("", "default", "")},
+			&corev1.Pod{ObjectMeta: meta("default", "pinned", "sidecar-image-mismatch, IV0301")},
+			&corev1.Pod{ObjectMeta: meta("default", "plain", "")},
+			&corev1.Service{ObjectMeta: meta("default", "svc", "missing-service-port-prefix")},
+		)
+		istioClient := istiofake.NewSimpleClientset(
+			&istionetv1beta1.VirtualService{ObjectMeta: meta("default", "vs", "host-in-multiple-vs")},
+		)
+		f := &factory{
+			k8s:   informers.NewSharedInformerFactory(k8sClient, 0),
+			istio: istioinformer.NewSharedInformerFactory(istioClient, 0),
+		}
+		s = vetter.NewSuppressor(f)
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		f.k8s.Start(stopCh)
+		f.istio.Start(stopCh)
+		f.k8s.WaitForCacheSync(stopCh)
+		f.istio.WaitForCacheSync(stopCh)
+	})
+
+	It("suppresses notes by the type or code ignored on their objects", func() {
+		pinned := ref("", "Pod", "default", "pinned")
+		Expect(s.Suppress(&apiv1.Note{Type: "sidecar-image-mismatch", Refs: []*apiv1.ObjectReference{pinned}})).To(BeTrue())
+		Expect(s.Suppress(&apiv1.Note{Type: "missing-app-label", Code: "IV0301", Refs: []*apiv1.ObjectReference{pinned}})).To(BeTrue())
+		Expect(s.Suppress(&apiv1.Note{Type: "init-image-mismatch", Refs: []*apiv1.ObjectReference{pinned}})).To(BeFalse())
+		Expect(s.Suppress(&apiv1.Note{Type: "sidecar-image-mismatch",
+			Refs: []*apiv1.ObjectReference{ref("", "Pod", "default", "plain")}})).To(BeFalse())
+
+		Expect(s.Suppress(&apiv1.Note{Type: "missing-service-port-prefix",
+			Refs: []*apiv1.ObjectReference{ref("", "Service", "default", "svc")}})).To(BeTrue())
+		Expect(s.Suppress(&apiv1.Note{Type: "host-in-multiple-vs",
+			Refs: []*apiv1.ObjectReference{ref("networking.istio.io", "VirtualService", "default", "vs")}})).To(BeTrue())
+	})
+
+	It("suppresses notes in ignored namespaces", func() {
+		Expect(s.Suppress(&apiv1.Note{Type: "missing-app-label",
+			Refs: []*apiv1.ObjectReference{ref("", "Pod", "quiet", "gone")}})).To(BeTrue())
+		Expect(s.Suppress(&apiv1.Note{Type: "missing-app-label",
+			Attr: map[string]string{"namespace": "quiet"}})).To(BeTrue())
+		Expect(s.Suppress(&apiv1.Note{Type: "missing-app-label",
+			Attr: map[string]string{"namespace": "default"}})).To(BeFalse())
+	})
+
+	It("drops suppressed notes and counts them", func() {
+		v := &wrapper{s.Instrument([]vetter.Vetter{&notesVetter{notes: []*apiv1.Note{
+			{Type: "sidecar-image-mismatch", Refs: []*apiv1.ObjectReference{ref("", "Pod", "default", "pinned")}},
+			{Type: "sidecar-image-mismatch", Refs: []*apiv1.ObjectReference{ref("", "Pod", "default", "plain")}},
+		}}})[0]}
+		notes, err := v.Vet()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(HaveLen(1))
+		Expect(notes[0].GetRefs()[0].GetName()).To(Equal("plain"))
+		Expect(vetter.Suppressed(v)).To(Equal(1))
+		Expect(vetter.Suppressed(&notesVetter{})).To(Equal(0))
+
+		By("resetting the count when a run fails")
+		failing := &failingVetter{}
+		v = &wrapper{s.Instrument([]vetter.Vetter{failing})[0]}
+		failing.notes = []*apiv1.Note{
+			{Type: "sidecar-image-mismatch", Refs: []*apiv1.ObjectReference{ref("", "Pod", "default", "pinned")}}}
+		_, err = v.Vet()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vetter.Suppressed(v)).To(Equal(1))
+		failing.err = errors.New("boom")
+		_, err = v.Vet()
+		Expect(err).To(HaveOccurred())
+		Expect(vetter.Suppressed(v)).To(Equal(0))
+	})
+})
@@ -17,14 +17,23 @@ limitations under the License.
 // Package vetter contains interfaces which vetters should implement.
 // All vetter(s) packages must export
 //  func NewVetter(factory vetter.ResourceListGetter) *newVetter
-// where newVetter implements the Vetter interface described below.
+// where newVetter implements the Vetter interface described below, and
+//  var NoteTypes []*vetter.NoteType
+// documenting the types of notes the vetter generates. They register
+// themselves with Register from an init function, and are imported by
+// package all so the vet command runs them. Vetters accepting configuration
+// implement the Configurable interface, long running vetters the
+// ContextVetter interface.
 package vetter
 
 import (
+	"context"
+
 	"istio.io/client-go/pkg/informers/externalversions"
 	"k8s.io/client-go/informers"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 )
 
 // Vetter interface is implemented by vetters.
@@ -37,6 +46,15 @@ type Vetter interface {
 	Info() *apiv1.Info
 }
 
+// ContextVetter is implemented by vetters which can be cancelled, e.g.
+// when they time out.
+type ContextVetter interface {
+	Vetter
+
+	// VetContext is like Vet, but returns ctx.Err() once ctx is done.
+	VetContext(ctx context.Context) ([]*apiv1.Note, error)
+}
+
 // ResourceListGetter is used by vetters to register for and list resources they are interested in.
 // This currently exposes the Informer interface, but that should not be used.
 // Only the "Lister()" interfaces should be considered public.
@@ -44,3 +62,18 @@ type ResourceListGetter interface {
 	K8s() informers.SharedInformerFactory
 	Istio() externalversions.SharedInformerFactory
 }
+
+// EnvironmentGetter is implemented by ResourceListGetters knowing where Istio
+// is installed in the cluster.
+type EnvironmentGetter interface {
+	Environment() util.Environment
+}
+
+// Environment returns the environment of factory if it implements
+// EnvironmentGetter, the default environment otherwise.
+func Environment(factory ResourceListGetter) util.Environment {
+	if g, ok := factory.(EnvironmentGetter); ok {
+		return g.Environment()
+	}
+	return util.Environment{}
+}
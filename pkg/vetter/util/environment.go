@@ -0,0 +1,135 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// istiodLabelSelector selects the istiod deployments of all revisions.
+const istiodLabelSelector = "app=istiod"
+
+// Environment describes where Istio is installed in the cluster. The zero
+// value is the default environment, Istio installed in "istio-system" of a
+// cluster with the "cluster.local" domain.
+type Environment struct {
+	// IstioNamespace is the namespace of the Istio control plane, holding
+	// the injector and mesh configmaps. Defaults to "istio-system".
+	IstioNamespace string
+
+	// ClusterDomain is the DNS domain of the cluster, services are named
+	// <name>.<namespace>.svc.<ClusterDomain>. Defaults to
+	// DefaultClusterDomain.
+	ClusterDomain string
+}
+
+// defaultIstioNamespace is the Istio namespace of the default environment.
+const defaultIstioNamespace = "istio-system"
+
+func (e Environment) istioNamespace() string {
+	if e.IstioNamespace == "" {
+		return defaultIstioNamespace
+	}
+	return e.IstioNamespace
+}
+
+func (e Environment) clusterDomain() string {
+	if e.ClusterDomain == "" {
+		return DefaultClusterDomain
+	}
+	return e.ClusterDomain
+}
+
+// DomainSuffix returns the suffix of the FQDN of services, e.g.
+// ".svc.cluster.local".
+func (e Environment) DomainSuffix() string {
+	return ".svc." + e.clusterDomain()
+}
+
+// ExemptedNamespaces returns the Namespaces which are exempted from
+// automatic sidecar injection: "kube-system", "kube-public" and the Istio
+// namespace.
+func (e Environment) ExemptedNamespaces() []string {
+	return []string{"kube-system", "kube-public", e.istioNamespace()}
+}
+
+// ExemptedNamespace checks if a Namespace is exempted from automatic sidecar
+// injection.
+func (e Environment) ExemptedNamespace(ns string) bool {
+	for _, exempted := range e.ExemptedNamespaces() {
+		if ns == exempted {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoverEnvironment returns env with its empty fields discovered from the
+// cluster: the Istio namespace is the namespace of the istiod deployment,
+// preferring the default revision, and the cluster domain is the trust
+// domain of its MeshConfig, which Istio defaults to the cluster domain.
+// Fields which can't be discovered, e.g. without permissions to list
+// deployments, are left empty and so have their default value.
+func DiscoverEnvironment(ctx context.Context, k8sClient kubernetes.Interface, env Environment) Environment {
+	if env.IstioNamespace == "" {
+		env.IstioNamespace = discoverIstioNamespace(ctx, k8sClient)
+	}
+	if env.ClusterDomain == "" {
+		env.ClusterDomain = discoverClusterDomain(ctx, k8sClient, env.istioNamespace())
+	}
+	glog.V(2).Infof("Using Istio namespace %s and cluster domain %s",
+		env.istioNamespace(), env.clusterDomain())
+	return env
+}
+
+func discoverIstioNamespace(ctx context.Context, k8sClient kubernetes.Interface) string {
+	deployments, err := k8sClient.AppsV1().Deployments(metav1.NamespaceAll).List(ctx,
+		metav1.ListOptions{LabelSelector: istiodLabelSelector})
+	if err != nil {
+		glog.Warningf("Failed to discover the Istio namespace, using %s: %s", defaultIstioNamespace, err)
+		return ""
+	}
+	if len(deployments.Items) == 0 {
+		return ""
+	}
+	d := deployments.Items
+	sort.Slice(d, func(i, j int) bool { return d[i].Namespace < d[j].Namespace })
+	for _, istiod := range d {
+		if rev := istiod.Labels[IstioRevisionLabel]; rev == "" || rev == IstioDefaultRevision {
+			return istiod.Namespace
+		}
+	}
+	return d[0].Namespace
+}
+
+func discoverClusterDomain(ctx context.Context, k8sClient kubernetes.Interface, istioNamespace string) string {
+	cm, err := k8sClient.CoreV1().ConfigMaps(istioNamespace).Get(ctx, IstioConfigMap, metav1.GetOptions{})
+	if err != nil {
+		glog.V(2).Infof("Failed to discover the cluster domain, using %s: %s", DefaultClusterDomain, err)
+		return ""
+	}
+	mc, err := GetMeshConfig(cm)
+	if err != nil || mc == nil {
+		return ""
+	}
+	return mc.GetTrustDomain()
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	v1 "k8s.io/client-go/listers/core/v1"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("Environment", func() {
+	istiod := func(namespace, rev string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "istiod-" + rev,
+			Labels:    map[string]string{"app": "istiod", IstioRevisionLabel: rev},
+		}}
+	}
+	meshConfigMap := func(namespace, trustDomain string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: IstioConfigMap},
+			Data:       map[string]string{IstioConfigMapKey: "trustDomain: " + trustDomain},
+		}
+	}
+
+	It("defaults to istio-system and cluster.local", func() {
+		env := Environment{}
+		Expect(env.DomainSuffix()).To(Equal(".svc.cluster.local"))
+		Expect(env.ExemptedNamespace("istio-system")).To(BeTrue())
+		Expect(env.ExemptedNamespace("kube-system")).To(BeTrue())
+		Expect(env.ExemptedNamespace("default")).To(BeFalse())
+		Expect(env.ExemptedNamespaces()).To(ConsistOf("kube-system", "kube-public", "istio-system"))
+	})
+
+	It("uses a custom Istio namespace and cluster domain", func() {
+		env := Environment{IstioNamespace: "istio-control", ClusterDomain: "corp.example"}
+		Expect(env.ExemptedNamespace("istio-control")).To(BeTrue())
+		Expect(env.ExemptedNamespace("istio-system")).To(BeFalse())
+		Expect(env.ConvertHostnameToFQDN("foo", "bar")).To(Equal("foo.bar.svc.corp.example"))
+		Expect(env.ConvertHostnameToFQDN("foo.bar.svc.cluster.local", "bar")).To(Equal("foo.bar.svc.cluster.local"))
+
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(indexer.Add(meshConfigMap("istio-control", "corp.example"))).To(Succeed())
+		cmLister := v1.NewConfigMapLister(indexer)
+		_, err := env.GetRevisionMeshConfigMap(cmLister, IstioDefaultRevision)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = Environment{}.GetRevisionMeshConfigMap(cmLister, IstioDefaultRevision)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("discovers the namespace of the default istiod and its trust domain", func() {
+		client := fake.NewSimpleClientset(
+			istiod("istio-canary", "canary"), istiod("istio-control", "default"),
+			meshConfigMap("istio-control", "corp.example"))
+		env := DiscoverEnvironment(context.Background(), client, Environment{})
+		Expect(env).To(Equal(Environment{IstioNamespace: "istio-control", ClusterDomain: "corp.example"}))
+
+		env = DiscoverEnvironment(context.Background(), client, Environment{ClusterDomain: "cluster.local"})
+		Expect(env).To(Equal(Environment{IstioNamespace: "istio-control", ClusterDomain: "cluster.local"}))
+
+		env = DiscoverEnvironment(context.Background(), client, Environment{IstioNamespace: "istio-canary"})
+		Expect(env).To(Equal(Environment{IstioNamespace: "istio-canary"}))
+	})
+
+	It("keeps the defaults if it can't discover the environment", func() {
+		client := fake.NewSimpleClientset(meshConfigMap("istio-system", "corp.example"))
+		client.PrependReactor("list", "deployments", func(a k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "deployments"}, "", nil)
+		})
+		env := DiscoverEnvironment(context.Background(), client, Environment{})
+		Expect(env).To(Equal(Environment{ClusterDomain: "corp.example"}))
+
+		env = DiscoverEnvironment(context.Background(), fake.NewSimpleClientset(), Environment{})
+		Expect(env).To(Equal(Environment{}))
+	})
+})
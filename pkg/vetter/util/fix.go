@@ -0,0 +1,133 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/golang/glog"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+)
+
+// Kinds of the workloads managing pods
+var (
+	DeploymentKind  = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	StatefulSetKind = appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+	DaemonSetKind   = appsv1.SchemeGroupVersion.WithKind("DaemonSet")
+	ReplicaSetKind  = appsv1.SchemeGroupVersion.WithKind("ReplicaSet")
+	JobKind         = batchv1.SchemeGroupVersion.WithKind("Job")
+)
+
+// NewFix returns a Fix applying patch, marshalled to JSON, to target. Map
+// keys are marshalled in sorted order, so the note ids are stable.
+func NewFix(description string, target *apiv1.ObjectReference, patchType apiv1.PatchType, patch interface{}) *apiv1.Fix {
+	b, err := json.Marshal(patch)
+	if err != nil {
+		glog.Errorf("Failed to marshal patch for %s/%s: %s", target.GetNamespace(), target.GetName(), err)
+		return nil
+	}
+	return &apiv1.Fix{
+		Description: description,
+		Target:      target,
+		Type:        patchType,
+		Patch:       string(b),
+	}
+}
+
+// PodTemplateFix returns a Fix adding labels and annotations to the pod
+// template of workload. Changing the template rolls out new pods.
+func PodTemplateFix(description string, workload *apiv1.ObjectReference, labels, annotations map[string]string) *apiv1.Fix {
+	meta := map[string]interface{}{}
+	if len(labels) > 0 {
+		meta["labels"] = labels
+	}
+	if len(annotations) > 0 {
+		meta["annotations"] = annotations
+	}
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"metadata": meta},
+		},
+	}
+	return NewFix(description, workload, apiv1.PatchType_STRATEGIC_MERGE_PATCH, patch)
+}
+
+// Workload returns a reference to the Deployment, StatefulSet or DaemonSet
+// managing pod p, or nil if it isn't managed by one of them.
+//
+// Pods of a Deployment are owned by a ReplicaSet named after the Deployment
+// and the "pod-template-hash" label, which is how the Deployment is found
+// without listing ReplicaSets.
+func Workload(p *corev1.Pod) *apiv1.ObjectReference {
+	owner := metav1.GetControllerOf(p)
+	if owner == nil {
+		return nil
+	}
+	switch owner.Kind {
+	case "ReplicaSet":
+		hash := p.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		if hash == "" || !strings.HasSuffix(owner.Name, "-"+hash) {
+			return nil
+		}
+		return ObjectRef(DeploymentKind, p.Namespace, strings.TrimSuffix(owner.Name, "-"+hash), "")
+	case StatefulSetKind.Kind:
+		return ObjectRef(StatefulSetKind, p.Namespace, owner.Name, owner.UID)
+	case DaemonSetKind.Kind:
+		return ObjectRef(DaemonSetKind, p.Namespace, owner.Name, owner.UID)
+	}
+	return nil
+}
+
+// Owner returns a reference to the workload owning pod p: the Deployment
+// owning its ReplicaSet, looked up with rsLister, or its ReplicaSet if it
+// isn't owned by a Deployment, or its StatefulSet, DaemonSet or Job. It
+// returns nil for pods without controller or with another controller.
+//
+// Unlike Workload, the Deployment is found even if the ReplicaSet isn't
+// named after it, and Jobs are returned, whose pod template can't be
+// patched. If rsLister is nil or the ReplicaSet isn't found, Owner falls
+// back to Workload for ReplicaSets.
+func Owner(p *corev1.Pod, rsLister appslisters.ReplicaSetLister) *apiv1.ObjectReference {
+	owner := metav1.GetControllerOf(p)
+	if owner == nil {
+		return nil
+	}
+	switch owner.Kind {
+	case ReplicaSetKind.Kind:
+		if rsLister == nil {
+			return Workload(p)
+		}
+		rs, err := rsLister.ReplicaSets(p.Namespace).Get(owner.Name)
+		if err != nil {
+			return Workload(p)
+		}
+		if d := metav1.GetControllerOf(rs); d != nil && d.Kind == DeploymentKind.Kind {
+			return ObjectRef(DeploymentKind, p.Namespace, d.Name, d.UID)
+		}
+		return ObjectRef(ReplicaSetKind, p.Namespace, rs.Name, rs.UID)
+	case JobKind.Kind:
+		return ObjectRef(JobKind, p.Namespace, owner.Name, owner.UID)
+	}
+	return Workload(p)
+}
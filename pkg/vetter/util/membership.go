@@ -0,0 +1,241 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "k8s.io/client-go/listers/core/v1"
+)
+
+// InjectionReason is the reason the sidecar injector injects a pod or not.
+type InjectionReason string
+
+// Reasons of the injection decision, in the order the injector checks them.
+const (
+	// ReasonNamespaceIgnored is for pods in namespaces the injector never
+	// injects, "kube-system" and "kube-public".
+	ReasonNamespaceIgnored InjectionReason = "namespace-ignored"
+	// ReasonNamespaceNotInMesh is for pods in namespaces without injection
+	// enabled, which don't opt in with the sidecar.istio.io/inject label.
+	ReasonNamespaceNotInMesh InjectionReason = "namespace-not-in-mesh"
+	// ReasonHostNetwork is for pods using the host network, never injected.
+	ReasonHostNetwork InjectionReason = "host-network"
+	// ReasonPodLabel is for pods with a sidecar.istio.io/inject label.
+	ReasonPodLabel InjectionReason = "pod-label"
+	// ReasonPodAnnotation is for pods with a sidecar.istio.io/inject
+	// annotation and no label.
+	ReasonPodAnnotation InjectionReason = "pod-annotation"
+	// ReasonNeverInjectSelector is for pods matching a neverInjectSelector
+	// of the injector.
+	ReasonNeverInjectSelector InjectionReason = "never-inject-selector"
+	// ReasonAlwaysInjectSelector is for pods matching an
+	// alwaysInjectSelector of the injector.
+	ReasonAlwaysInjectSelector InjectionReason = "always-inject-selector"
+	// ReasonInjectorPolicy is for pods injected according to the policy of
+	// the injector, "enabled" or "disabled".
+	ReasonInjectorPolicy InjectionReason = "injector-policy"
+)
+
+// injectionIgnoredNamespaces are never injected by the sidecar injector.
+var injectionIgnoredNamespaces = map[string]bool{
+	metav1.NamespaceSystem: true,
+	metav1.NamespacePublic: true,
+}
+
+// MeshMembership explains why a pod is or isn't in the mesh.
+type MeshMembership struct {
+	// Pod is the pod whose membership is explained.
+	Pod *corev1.Pod
+
+	// Injected is true if the pod runs a sidecar proxy, as determined by
+	// SidecarInjected. Pods are in the mesh if they are injected.
+	Injected bool
+
+	// NativeSidecar is true if the proxy runs as a native sidecar, an init
+	// container of the pod.
+	NativeSidecar bool
+
+	// InjectionRequired is true if the sidecar injector injects the pod
+	// when it is created, given the pod and its namespace as they are now.
+	InjectionRequired bool
+
+	// Reason is why the injection is required or not, Detail describes it
+	// for this pod.
+	Reason InjectionReason
+	Detail string
+
+	// Revision is the Istio revision injecting the pod.
+	Revision string
+}
+
+// String describes the membership, e.g. "not injected, injection not
+// required: pod label sidecar.istio.io/inject=false".
+func (m MeshMembership) String() string {
+	state := "not injected"
+	if m.Injected {
+		state = "injected"
+		if m.NativeSidecar {
+			state += " as native sidecar"
+		}
+	}
+	required := "injection not required"
+	if m.InjectionRequired {
+		required = "injection required"
+	}
+	return fmt.Sprintf("%s, %s: %s", state, required, m.Detail)
+}
+
+// parseInject returns the value of a sidecar.istio.io/inject label or
+// annotation as the injector does.
+func parseInject(v string) bool {
+	switch strings.ToLower(v) {
+	case "", "y", "yes", "true", "on":
+		return true
+	}
+	return false
+}
+
+// matchingSelector returns the first of selectors matching the labels of p,
+// or nil.
+func matchingSelector(p *corev1.Pod, selectors []metav1.LabelSelector) labels.Selector {
+	for i := range selectors {
+		sel, err := metav1.LabelSelectorAsSelector(&selectors[i])
+		if err != nil {
+			glog.Errorf("Invalid injector label selector: %s", err)
+			continue
+		}
+		if !sel.Empty() && sel.Matches(labels.Set(p.Labels)) {
+			return sel
+		}
+	}
+	return nil
+}
+
+// PodMeshMembership returns the MeshMembership of pod p in namespace ns,
+// which may be nil if it isn't known. cfg is the config of the injector of
+// the revision of the pod, given by PodRevision, or nil if it isn't known,
+// in which case the injector is assumed to have the default "enabled"
+// policy.
+func PodMeshMembership(p *corev1.Pod, ns *corev1.Namespace, cfg *IstioInjectConfig) MeshMembership {
+	c, native := proxyContainer(p.Spec)
+	m := MeshMembership{
+		Pod:           p,
+		Injected:      SidecarInjected(p),
+		NativeSidecar: c != nil && native,
+		Revision:      PodRevision(p, ns),
+	}
+	required, reason, detail := injectionRequired(p, ns, cfg, m.Revision)
+	m.InjectionRequired, m.Reason, m.Detail = required, reason, detail
+	return m
+}
+
+func injectionRequired(p *corev1.Pod, ns *corev1.Namespace, cfg *IstioInjectConfig,
+	revision string) (bool, InjectionReason, string) {
+	if injectionIgnoredNamespaces[p.Namespace] {
+		return false, ReasonNamespaceIgnored, "namespace " + p.Namespace + " is never injected"
+	}
+
+	// The injector webhook is called for pods in namespaces with injection
+	// enabled, and for pods opting in with a label in namespaces which
+	// don't disable injection.
+	label, hasLabel := p.Labels[IstioSidecarInjectKey]
+	if ns == nil || !NamespaceInMesh(ns) {
+		nsInjection := ""
+		if ns != nil {
+			nsInjection = ns.Labels[IstioInjectionLabel]
+		}
+		optIn := (hasLabel && label == "true") || p.Labels[IstioRevisionLabel] != ""
+		if nsInjection == "disabled" || !optIn {
+			detail := "namespace " + p.Namespace + " has no " + IstioInjectionLabel + "=enabled or " +
+				IstioRevisionLabel + " label"
+			if nsInjection != "" {
+				detail = "namespace " + p.Namespace + " has label " + IstioInjectionLabel + "=" + nsInjection
+			}
+			return false, ReasonNamespaceNotInMesh, detail
+		}
+	}
+
+	if p.Spec.HostNetwork {
+		return false, ReasonHostNetwork, "pod uses the host network"
+	}
+
+	if hasLabel {
+		return parseInject(label), ReasonPodLabel, "pod label " + IstioSidecarInjectKey + "=" + label
+	}
+	if a, ok := p.Annotations[IstioSidecarInjectKey]; ok {
+		return parseInject(a), ReasonPodAnnotation, "pod annotation " + IstioSidecarInjectKey + "=" + a
+	}
+
+	policy := InjectionPolicyEnabled
+	if cfg != nil {
+		if sel := matchingSelector(p, cfg.NeverInjectSelector); sel != nil {
+			return false, ReasonNeverInjectSelector, "pod labels match neverInjectSelector " + sel.String()
+		}
+		if sel := matchingSelector(p, cfg.AlwaysInjectSelector); sel != nil {
+			return true, ReasonAlwaysInjectSelector, "pod labels match alwaysInjectSelector " + sel.String()
+		}
+		if cfg.Policy != "" {
+			policy = cfg.Policy
+		}
+	}
+	return policy != InjectionPolicyDisabled, ReasonInjectorPolicy,
+		"injector policy of revision " + revision + " is " + string(policy)
+}
+
+// ListPodMeshMemberships returns the MeshMembership of the pods in the
+// Namespaces listed by nsLister. The injector config of their
+// revision is read with cmLister from the Istio namespace of e, pods of
+// revisions without readable config get the default "enabled" policy.
+func (e Environment) ListPodMeshMemberships(nsLister v1.NamespaceLister, podLister v1.PodLister,
+	cmLister v1.ConfigMapLister) ([]MeshMembership, error) {
+	ns, err := nsLister.List(labels.Everything())
+	if err != nil {
+		glog.Error("Failed to retrieve namespaces: ", err)
+		return nil, err
+	}
+	configs := map[string]*IstioInjectConfig{}
+	injectConfig := func(revision string) *IstioInjectConfig {
+		if cfg, ok := configs[revision]; ok {
+			return cfg
+		}
+		var cfg *IstioInjectConfig
+		if cm, err := e.GetRevisionInitializerConfigMap(cmLister, revision); err == nil {
+			cfg, _ = GetIstioInjectConfig(cm)
+		}
+		configs[revision] = cfg
+		return cfg
+	}
+
+	memberships := []MeshMembership{}
+	for _, n := range ns {
+		podList, err := podLister.Pods(n.Name).List(labels.Everything())
+		if err != nil {
+			glog.Errorf("Failed to retrieve pods for namespace: %s error: %s", n.Name, err)
+			return nil, err
+		}
+		for _, p := range podList {
+			memberships = append(memberships, PodMeshMembership(p, n, injectConfig(PodRevision(p, n))))
+		}
+	}
+	return memberships, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("Mesh membership", func() {
+	namespace := func(name string, l map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: l}}
+	}
+	pod := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace, Name: name, Labels: map[string]string{}, Annotations: map[string]string{}}}
+	}
+	inject := func(p *corev1.Pod, native bool) *corev1.Pod {
+		p.Annotations[IstioInitializerPodAnnotation] = "{}"
+		proxy := corev1.Container{Name: IstioProxyContainerName, Image: "docker.io/istio/proxyv2:1.1.2"}
+		if native {
+			p.Spec.InitContainers = append(p.Spec.InitContainers, proxy)
+		} else {
+			p.Spec.Containers = append(p.Spec.Containers, proxy)
+		}
+		return p
+	}
+	web := namespace("web", map[string]string{IstioInjectionLabel: "enabled"})
+	legacy := namespace("legacy", nil)
+	disabled := namespace("disabled", map[string]string{IstioInjectionLabel: "disabled"})
+
+	It("detects injected pods and native sidecars", func() {
+		m := PodMeshMembership(inject(pod("web", "a"), false), web, nil)
+		Expect(m.Injected).To(BeTrue())
+		Expect(m.NativeSidecar).To(BeFalse())
+		Expect(m.InjectionRequired).To(BeTrue())
+		Expect(m.Reason).To(Equal(ReasonInjectorPolicy))
+		Expect(m.Revision).To(Equal(IstioDefaultRevision))
+		Expect(m.String()).To(Equal("injected, injection required: injector policy of revision default is enabled"))
+
+		m = PodMeshMembership(inject(pod("web", "a"), true), web, nil)
+		Expect(m.Injected).To(BeTrue())
+		Expect(m.NativeSidecar).To(BeTrue())
+		image, err := ProxyImage(m.Pod.Spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(Equal("docker.io/istio/proxyv2:1.1.2"))
+
+		m = PodMeshMembership(pod("web", "a"), web, nil)
+		Expect(m.Injected).To(BeFalse())
+		Expect(m.InjectionRequired).To(BeTrue())
+	})
+
+	It("honors the namespace labels", func() {
+		m := PodMeshMembership(pod("legacy", "a"), legacy, nil)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonNamespaceNotInMesh))
+
+		m = PodMeshMembership(pod("kube-system", "a"), namespace("kube-system", web.Labels), nil)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonNamespaceIgnored))
+
+		// Pods can opt in unless the namespace disables injection
+		p := pod("legacy", "a")
+		p.Labels[IstioSidecarInjectKey] = "true"
+		m = PodMeshMembership(p, legacy, nil)
+		Expect(m.InjectionRequired).To(BeTrue())
+		Expect(m.Reason).To(Equal(ReasonPodLabel))
+
+		p.Namespace = "disabled"
+		m = PodMeshMembership(p, disabled, nil)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonNamespaceNotInMesh))
+		Expect(m.Detail).To(Equal("namespace disabled has label istio-injection=disabled"))
+	})
+
+	It("honors the pod label and annotation", func() {
+		p := pod("web", "a")
+		p.Annotations[IstioSidecarInjectKey] = "false"
+		m := PodMeshMembership(p, web, nil)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonPodAnnotation))
+
+		p.Labels[IstioSidecarInjectKey] = "true"
+		m = PodMeshMembership(p, web, nil)
+		Expect(m.InjectionRequired).To(BeTrue())
+		Expect(m.Reason).To(Equal(ReasonPodLabel))
+
+		p.Spec.HostNetwork = true
+		m = PodMeshMembership(p, web, nil)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonHostNetwork))
+	})
+
+	It("honors the injector policy and selectors", func() {
+		cfg := &IstioInjectConfig{
+			Policy:               InjectionPolicyDisabled,
+			NeverInjectSelector:  []metav1.LabelSelector{{MatchLabels: map[string]string{"job": "batch"}}},
+			AlwaysInjectSelector: []metav1.LabelSelector{{MatchLabels: map[string]string{"tier": "web"}}},
+		}
+		p := pod("web", "a")
+		m := PodMeshMembership(p, web, cfg)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonInjectorPolicy))
+
+		p.Labels["tier"] = "web"
+		m = PodMeshMembership(p, web, cfg)
+		Expect(m.InjectionRequired).To(BeTrue())
+		Expect(m.Reason).To(Equal(ReasonAlwaysInjectSelector))
+		Expect(m.Detail).To(Equal("pod labels match alwaysInjectSelector tier=web"))
+
+		p.Labels["job"] = "batch"
+		m = PodMeshMembership(p, web, cfg)
+		Expect(m.InjectionRequired).To(BeFalse())
+		Expect(m.Reason).To(Equal(ReasonNeverInjectSelector))
+
+		// An explicit opt in takes precedence over the selectors
+		p.Annotations[IstioSidecarInjectKey] = "yes"
+		m = PodMeshMembership(p, web, cfg)
+		Expect(m.InjectionRequired).To(BeTrue())
+		Expect(m.Reason).To(Equal(ReasonPodAnnotation))
+	})
+
+	It("lists the membership of pods with the config of their revision", func() {
+		nsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		cmIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(nsIndexer.Add(web)).To(Succeed())
+		Expect(nsIndexer.Add(legacy)).To(Succeed())
+		Expect(podIndexer.Add(pod("web", "a"))).To(Succeed())
+		Expect(podIndexer.Add(inject(pod("legacy", "b"), false))).To(Succeed())
+		Expect(cmIndexer.Add(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: IstioInitializerConfigMap},
+			Data:       map[string]string{IstioInitializerConfigMapKey: "policy: disabled\nalwaysInjectSelector:\n- matchLabels: {tier: web}\n"},
+		})).To(Succeed())
+		nsLister := v1.NewNamespaceLister(nsIndexer)
+		podLister := v1.NewPodLister(podIndexer)
+
+		memberships, err := Environment{}.ListPodMeshMemberships(nsLister, podLister, v1.NewConfigMapLister(cmIndexer))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberships).To(HaveLen(2))
+		byName := map[string]MeshMembership{}
+		for _, m := range memberships {
+			byName[m.Pod.Name] = m
+		}
+		Expect(byName["a"].InjectionRequired).To(BeFalse())
+		Expect(byName["a"].Detail).To(Equal("injector policy of revision default is disabled"))
+		Expect(byName["b"].Injected).To(BeTrue())
+		Expect(byName["b"].Reason).To(Equal(ReasonNamespaceNotInMesh))
+
+		// Injected pods are in the mesh, even in namespaces without injection
+		pods, err := ListPodsInMesh(nsLister, podLister)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pods).To(HaveLen(1))
+		Expect(pods[0].Name).To(Equal("b"))
+	})
+})
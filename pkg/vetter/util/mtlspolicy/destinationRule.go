@@ -21,6 +21,8 @@ import (
 
 	istioNet "istio.io/api/networking/v1beta1"
 	istioClientNet "istio.io/client-go/pkg/apis/networking/v1beta1"
+
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 )
 
 // Destination Rules can have arbitrary PortTrafficPolicy; we don't want to
@@ -210,8 +212,9 @@ func (dr *DestRules) TLSByName(s Service) (bool, *istioClientNet.DestinationRule
 }
 
 // LoadDestRules is passed a list of Destination Rules and returns a DestRules
-// with each of the Destination Rules mapped by port, name, and namespace
-func LoadDestRules(rules []*istioClientNet.DestinationRule) (*DestRules, error) {
+// with each of the Destination Rules mapped by port, name, and namespace.
+// Rules for hosts outside the cluster domain of env are skipped.
+func LoadDestRules(env util.Environment, rules []*istioClientNet.DestinationRule) (*DestRules, error) {
 	loaded := NewDestRules()
 	for _, r := range rules {
 		host := r.Spec.Host
@@ -219,7 +222,7 @@ func LoadDestRules(rules []*istioClientNet.DestinationRule) (*DestRules, error)
 			// Host is REQUIRED according to Istio so skip this invalid rule
 			continue
 		}
-		s, err := ServiceFromFqdn(host)
+		s, err := ServiceFromFqdn(env, host)
 		if err != nil || r.Spec.GetTrafficPolicy() == nil {
 			// Rule refers to a non-mesh service or has no TLS settings, skip.
 			continue
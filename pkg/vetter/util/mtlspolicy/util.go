@@ -30,13 +30,13 @@ type Service struct {
 	Namespace string
 }
 
-// ServiceFromFqdn validates a kubernetes FQDN and returns a service with the
-// name and namespace from a validated FQDN
-func ServiceFromFqdn(fqdn string) (Service, error) {
-	if !strings.HasSuffix(fqdn, util.KubernetesDomainSuffix) {
+// ServiceFromFqdn validates a kubernetes FQDN in the cluster domain of env and
+// returns a service with the name and namespace from a validated FQDN
+func ServiceFromFqdn(env util.Environment, fqdn string) (Service, error) {
+	if !strings.HasSuffix(fqdn, env.DomainSuffix()) {
 		return Service{}, errors.New("FQDN suffix unrecognized")
 	}
-	front := strings.TrimSuffix(fqdn, util.KubernetesDomainSuffix)
+	front := strings.TrimSuffix(fqdn, env.DomainSuffix())
 	parts := strings.Split(front, ".")
 	if len(parts) != 2 || len(parts[0]) < 1 || len(parts[1]) < 1 {
 		return Service{}, errors.New("FQDN does not have name and namespace")
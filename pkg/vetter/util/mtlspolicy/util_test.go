@@ -19,6 +19,8 @@ package mtlspolicyutil
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
 )
 
 var _ = Describe("ServiceFromFqdn", func() {
@@ -38,7 +40,7 @@ var _ = Describe("ServiceFromFqdn", func() {
 			},
 		}
 		for _, testCase := range passTestCases {
-			s, err := ServiceFromFqdn(testCase.Fqdn)
+			s, err := ServiceFromFqdn(util.Environment{}, testCase.Fqdn)
 			Expect(err).To(Succeed())
 			if err != nil {
 				continue
@@ -58,8 +60,17 @@ var _ = Describe("ServiceFromFqdn", func() {
 		}
 
 		for _, testCase := range failTestCases {
-			_, err := ServiceFromFqdn(testCase)
+			_, err := ServiceFromFqdn(util.Environment{}, testCase)
 			Expect(err).To(HaveOccurred())
 		}
 	})
+	It("should accept FQDNs in a custom cluster domain", func() {
+		env := util.Environment{ClusterDomain: "corp.example"}
+		s, err := ServiceFromFqdn(env, "foo.default.svc.corp.example")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(Service{Name: "foo", Namespace: "default"}))
+
+		_, err = ServiceFromFqdn(env, "foo.default.svc.cluster.local")
+		Expect(err).To(HaveOccurred())
+	})
 })
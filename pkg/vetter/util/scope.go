@@ -0,0 +1,185 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	istioversioned "istio.io/client-go/pkg/clientset/versioned"
+	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Scope limits a vet run to some namespaces. The zero value vets the whole
+// cluster.
+type Scope struct {
+	// Namespace is the only namespace vetted. Resources are only listed in
+	// this namespace, so a Role granting read access is enough.
+	Namespace string
+
+	// Selector selects the namespaces vetted by their labels. Resources
+	// are still listed in all namespaces.
+	Selector labels.Selector
+}
+
+// ParseScope returns the Scope vetting namespace, if not empty, or the
+// namespaces matching the label selector, if not empty.
+func ParseScope(namespace, selector string) (Scope, error) {
+	s := Scope{Namespace: namespace}
+	if selector == "" {
+		return s, nil
+	}
+	if namespace != "" {
+		return s, fmt.Errorf("a namespace and a namespace selector are mutually exclusive")
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return s, err
+	}
+	s.Selector = sel
+	return s, nil
+}
+
+// InformerFactories returns the informer factories listing the resources in
+// the scope from the clients. The namespace informer of the Kubernetes
+// factory only lists the namespaces in the scope, so the helpers listing the
+// resources in the mesh, like ListPodsInMesh, are limited to the scope as
+// well. ConfigMaps only hold the Istio configuration, so when vetting a
+// single namespace they are listed in the Istio namespace of env instead.
+func (s Scope) InformerFactories(k8sClient kubernetes.Interface, istioClient istioversioned.Interface,
+	env Environment) (informers.SharedInformerFactory, istioinformer.SharedInformerFactory) {
+	if s.Namespace == "" {
+		k8s := informers.NewSharedInformerFactory(k8sClient, 0)
+		if s.Selector != nil {
+			k8s.InformerFor(&corev1.Namespace{}, s.newNamespaceInformer)
+		}
+		return k8s, istioinformer.NewSharedInformerFactory(istioClient, 0)
+	}
+	k8s := informers.NewSharedInformerFactoryWithOptions(k8sClient, 0, informers.WithNamespace(s.Namespace))
+	k8s.InformerFor(&corev1.Namespace{}, s.newNamespaceInformer)
+	k8s.InformerFor(&corev1.ConfigMap{}, env.newConfigMapInformer)
+	istio := istioinformer.NewSharedInformerFactoryWithOptions(istioClient, 0,
+		istioinformer.WithNamespace(s.Namespace))
+	return k8s, istio
+}
+
+func (s Scope) newNamespaceInformer(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			s.restrict(&options)
+			list, err := client.CoreV1().Namespaces().List(context.TODO(), options)
+			if err != nil && s.Namespace != "" && errors.IsForbidden(err) {
+				glog.Warningf("Not allowed to read namespace %s, assuming it is in the mesh", s.Namespace)
+				return s.assumedNamespace(), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return s.filter(list), nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			s.restrict(&options)
+			w, err := client.CoreV1().Namespaces().Watch(context.TODO(), options)
+			if err != nil && s.Namespace != "" && errors.IsForbidden(err) {
+				// The assumed namespace never changes.
+				return watch.NewProxyWatcher(make(chan watch.Event)), nil
+			}
+			return w, err
+		},
+	}
+	return cache.NewSharedIndexInformer(lw, &corev1.Namespace{}, resync, cache.Indexers{})
+}
+
+// newConfigMapInformer returns an informer listing the ConfigMaps in the
+// Istio namespace. If they can't be read, none are listed, so the vetters
+// comparing with the Istio configuration report it as missing.
+func (e Environment) newConfigMapInformer(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+	namespace := e.istioNamespace()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := client.CoreV1().ConfigMaps(namespace).List(context.TODO(), options)
+			if err != nil && errors.IsForbidden(err) {
+				glog.Warningf("Not allowed to read the ConfigMaps in the Istio namespace %s, "+
+					"the Istio configuration can't be vetted", namespace)
+				return &corev1.ConfigMapList{}, nil
+			}
+			return list, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := client.CoreV1().ConfigMaps(namespace).Watch(context.TODO(), options)
+			if err != nil && errors.IsForbidden(err) {
+				return watch.NewProxyWatcher(make(chan watch.Event)), nil
+			}
+			return w, err
+		},
+	}
+	return cache.NewSharedIndexInformer(lw, &corev1.ConfigMap{}, resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+// restrict limits options to list the namespaces in the scope.
+func (s Scope) restrict(options *metav1.ListOptions) {
+	if s.Namespace != "" {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", s.Namespace).String()
+	}
+	if s.Selector != nil {
+		options.LabelSelector = s.Selector.String()
+	}
+}
+
+// filter drops the namespaces not in the scope from list, in case the
+// client ignores the selectors.
+func (s Scope) filter(list *corev1.NamespaceList) *corev1.NamespaceList {
+	items := []corev1.Namespace{}
+	for _, ns := range list.Items {
+		if s.Contains(&ns) {
+			items = append(items, ns)
+		}
+	}
+	list.Items = items
+	return list
+}
+
+// Contains returns true if ns is in the scope.
+func (s Scope) Contains(ns *corev1.Namespace) bool {
+	if s.Namespace != "" && ns.Name != s.Namespace {
+		return false
+	}
+	return s.Selector == nil || s.Selector.Matches(labels.Set(ns.Labels))
+}
+
+// assumedNamespace returns a list holding the namespace of the scope,
+// labeled to be in the mesh, for users not allowed to read it.
+func (s Scope) assumedNamespace() *corev1.NamespaceList {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   s.Namespace,
+		Labels: map[string]string{IstioInjectionLabel: "enabled"},
+	}}
+	return &corev1.NamespaceList{Items: []corev1.Namespace{ns}}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Scope", func() {
+	namespace := func(name string, l map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: l}}
+	}
+	pod := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	inMesh := map[string]string{"istio-injection": "enabled"}
+
+	var client *fake.Clientset
+	var stopCh chan struct{}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset(
+			namespace("web", map[string]string{"istio-injection": "enabled", "team": "web"}),
+			namespace("db", map[string]string{"istio-injection": "enabled", "team": "db"}),
+			pod("web", "web-1"), pod("db", "db-1"))
+		stopCh = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+	})
+
+	start := func(s Scope) informers.SharedInformerFactory {
+		k8s, _ := s.InformerFactories(client, istiofake.NewSimpleClientset(), Environment{})
+		k8s.Core().V1().Namespaces().Informer()
+		k8s.Core().V1().Pods().Informer()
+		k8s.Start(stopCh)
+		k8s.WaitForCacheSync(stopCh)
+		return k8s
+	}
+
+	names := func(k8s informers.SharedInformerFactory) []string {
+		ns, err := ListNamespacesInMesh(k8s.Core().V1().Namespaces().Lister())
+		Expect(err).NotTo(HaveOccurred())
+		var out []string
+		for _, n := range ns {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+
+	It("parses scopes", func() {
+		s, err := ParseScope("", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(Scope{}))
+		s, err = ParseScope("web", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Namespace).To(Equal("web"))
+		s, err = ParseScope("", "team=web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Contains(namespace("web", map[string]string{"team": "web"}))).To(BeTrue())
+		Expect(s.Contains(namespace("db", map[string]string{"team": "db"}))).To(BeFalse())
+		_, err = ParseScope("web", "team=web")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseScope("", "team in (")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lists all namespaces by default", func() {
+		Expect(names(start(Scope{}))).To(ConsistOf("web", "db"))
+	})
+
+	It("lists the resources of a single namespace", func() {
+		k8s := start(Scope{Namespace: "web"})
+		Expect(names(k8s)).To(ConsistOf("web"))
+		pods, err := k8s.Core().V1().Pods().Lister().List(labels.Everything())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pods).To(HaveLen(1))
+		Expect(pods[0].Name).To(Equal("web-1"))
+	})
+
+	It("lists the ConfigMaps of the Istio namespace when vetting a single namespace", func() {
+		configMap := func(namespace, name string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		}
+		client.Tracker().Add(configMap("istio-control", IstioInitializerConfigMap))
+		client.Tracker().Add(configMap("web", "web-config"))
+		k8s, _ := Scope{Namespace: "web"}.InformerFactories(client, istiofake.NewSimpleClientset(),
+			Environment{IstioNamespace: "istio-control"})
+		cmLister := k8s.Core().V1().ConfigMaps().Lister()
+		k8s.Start(stopCh)
+		k8s.WaitForCacheSync(stopCh)
+		cm, err := Environment{IstioNamespace: "istio-control"}.GetRevisionInitializerConfigMap(cmLister, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cm.Namespace).To(Equal("istio-control"))
+		_, err = cmLister.ConfigMaps("web").Get("web-config")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("assumes a namespace it can't read is in the mesh", func() {
+		forbidden := func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", nil)
+		}
+		client.PrependReactor("list", "namespaces", forbidden)
+		client.PrependWatchReactor("namespaces", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", nil)
+		})
+		k8s := start(Scope{Namespace: "web"})
+		ns, err := k8s.Core().V1().Namespaces().Lister().Get("web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Labels).To(Equal(inMesh))
+		Expect(names(k8s)).To(ConsistOf("web"))
+	})
+
+	It("lists the namespaces matching a selector", func() {
+		s, err := ParseScope("", "team=db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(start(s))).To(ConsistOf("db"))
+	})
+})
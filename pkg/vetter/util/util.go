@@ -19,14 +19,17 @@ limitations under the License.
 package util
 
 import (
+	"crypto/md5"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/cnf/structhash"
 	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
+	"google.golang.org/protobuf/proto"
 	meshv1alpha1 "istio.io/api/mesh/v1alpha1"
 	istioClientNet "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istioNetListers "istio.io/client-go/pkg/listers/networking/v1beta1"
@@ -34,14 +37,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	v1 "k8s.io/client-go/listers/core/v1"
 
 	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
 )
 
-// Constants related to Istio
+// Constants related to Istio. DefaultClusterDomain is the default of an
+// Environment.
 const (
-	IstioNamespace                = "istio-system"
 	IstioProxyContainerName       = "istio-proxy"
 	IstioInitContainerName        = "istio-init"
 	IstioConfigMap                = "istio"
@@ -49,8 +54,12 @@ const (
 	IstioInitializerPodAnnotation = "sidecar.istio.io/status"
 	IstioInitializerConfigMap     = "istio-sidecar-injector"
 	IstioInitializerConfigMapKey  = "config"
+	IstioInjectionLabel           = "istio-injection"
+	IstioRevisionLabel            = "istio.io/rev"
+	IstioSidecarInjectKey         = "sidecar.istio.io/inject"
+	IstioDefaultRevision          = "default"
 	IstioAppLabel                 = "app"
-	KubernetesDomainSuffix        = ".svc.cluster.local"
+	DefaultClusterDomain          = "cluster.local"
 	ServiceProtocolUDP            = "UDP"
 	initializerDisabled           = "configmaps \"" +
 		IstioInitializerConfigMap + "\" not found"
@@ -61,10 +70,6 @@ const (
 	kubernetesProxyStatusPortDefault = 15020
 )
 
-var istioInjectNamespaceLabel = map[string]string{
-	"istio-injection": "enabled",
-}
-
 // Config specifies the sidecar injection configuration This includes
 // the sidear template and cluster-side injection policy. It is used
 // by kube-inject, sidecar injector, and http endpoint.
@@ -74,6 +79,14 @@ type IstioInjectConfig struct {
 	// Template is the templated version of `SidecarInjectionSpec` prior to
 	// expansion over the `SidecarTemplateData`.
 	Template string `json:"template"`
+
+	// NeverInjectSelector refuses the injection on pods whose labels match
+	// any of these selectors. Takes precedence over AlwaysInjectSelector.
+	NeverInjectSelector []metav1.LabelSelector `json:"neverInjectSelector"`
+
+	// AlwaysInjectSelector forces the injection on pods whose labels match
+	// any of these selectors.
+	AlwaysInjectSelector []metav1.LabelSelector `json:"alwaysInjectSelector"`
 }
 
 var istioSupportedServicePrefix = []string{
@@ -88,38 +101,24 @@ var istioSupportedServicePrefix = []string{
 	"udp", "udp-",
 }
 
-var defaultExemptedNamespaces = map[string]bool{
-	"kube-system":  true,
-	"kube-public":  true,
-	"istio-system": true,
-}
-
-// DefaultExemptedNamespaces returns list of default Namsepaces which are
-// exempted from automatic sidecar injection.
-// List includes "kube-system", "kube-public" and "istio-system"
-func DefaultExemptedNamespaces() []string {
-	s := make([]string, len(defaultExemptedNamespaces))
-	i := 0
-	for k := range defaultExemptedNamespaces {
-		s[i] = k
-		i++
+// revisionName returns the name of the configmap of an Istio revision, name
+// suffixed with the revision unless it is the default one.
+func revisionName(name, revision string) string {
+	if revision == "" || revision == IstioDefaultRevision {
+		return name
 	}
-	return s
+	return name + "-" + revision
 }
 
-// ExemptedNamespace checks if a Namespace is by default exempted from automatic
-// sidecar injection.
-func ExemptedNamespace(ns string) bool {
-	return defaultExemptedNamespaces[ns]
-}
-
-// GetInitializerConfig retrieves the Istio Initializer config.
-// Istio Initializer config is stored as "istio-sidecar-injector" configmap in
-// "istio-system" Namespace.
-func GetInitializerConfigMap(cmLister v1.ConfigMapLister) (*corev1.ConfigMap, error) {
-	cm, err := cmLister.ConfigMaps(IstioNamespace).Get(IstioInitializerConfigMap)
+// GetRevisionInitializerConfigMap retrieves the Istio Initializer config of
+// an Istio revision, stored as "istio-sidecar-injector-<revision>" configmap,
+// or "istio-sidecar-injector" for the default revision, in the Istio
+// namespace of e.
+func (e Environment) GetRevisionInitializerConfigMap(cmLister v1.ConfigMapLister, revision string) (*corev1.ConfigMap, error) {
+	name := revisionName(IstioInitializerConfigMap, revision)
+	cm, err := cmLister.ConfigMaps(e.istioNamespace()).Get(name)
 	if err != nil {
-		glog.V(2).Infof("Failed to retrieve configmap: %s error: %s", IstioInitializerConfigMap, err)
+		glog.V(2).Infof("Failed to retrieve configmap: %s error: %s", name, err)
 		return nil, err
 	}
 	return cm, nil
@@ -141,13 +140,14 @@ func GetIstioInjectConfig(cm *corev1.ConfigMap) (*IstioInjectConfig, error) {
 	return &cfg, nil
 }
 
-// GetMeshConfig retrieves the Istio Mesh config.
-// Istio Mesh config is stored as "istio" configmap in
-// "istio-system" Namespace.
-func GetMeshConfigMap(cmLister v1.ConfigMapLister) (*corev1.ConfigMap, error) {
-	cm, err := cmLister.ConfigMaps(IstioNamespace).Get(IstioConfigMap)
+// GetRevisionMeshConfigMap retrieves the Istio Mesh config of an Istio
+// revision, stored as "istio-<revision>" configmap, or "istio" for the
+// default revision, in the Istio namespace of e.
+func (e Environment) GetRevisionMeshConfigMap(cmLister v1.ConfigMapLister, revision string) (*corev1.ConfigMap, error) {
+	name := revisionName(IstioConfigMap, revision)
+	cm, err := cmLister.ConfigMaps(e.istioNamespace()).Get(name)
 	if err != nil {
-		glog.Errorf("Failed to retrieve configmap: %s error: %s", IstioConfigMap, err)
+		glog.Errorf("Failed to retrieve configmap: %s error: %s", name, err)
 		return nil, err
 	}
 	return cm, nil
@@ -181,20 +181,46 @@ func makeSideCarSpec(icm, mcm *corev1.ConfigMap) (*SidecarInjectionSpec, error)
 	return spec, err
 }
 
-// GetInitializerSidecarSpec retrieves the sidecar spec which will be inserted
-// by the initializer
-func GetInitializerSidecarSpec(cmLister v1.ConfigMapLister) (*SidecarInjectionSpec, error) {
-	configMap, err := GetInitializerConfigMap(cmLister)
+// GetRevisionSidecarSpec retrieves the sidecar spec which will be inserted
+// by the initializer of an Istio revision, from the configmaps in the Istio
+// namespace of e.
+func (e Environment) GetRevisionSidecarSpec(cmLister v1.ConfigMapLister, revision string) (*SidecarInjectionSpec, error) {
+	configMap, err := e.GetRevisionInitializerConfigMap(cmLister, revision)
 	if err != nil {
 		return nil, err
 	}
-	meshConfigMap, err := GetMeshConfigMap(cmLister)
+	meshConfigMap, err := e.GetRevisionMeshConfigMap(cmLister, revision)
 	if err != nil {
 		return nil, err
 	}
 	return makeSideCarSpec(configMap, meshConfigMap)
 }
 
+// InjectImages holds the images of the istio-init and istio-proxy containers
+// injected by a sidecar injector.
+type InjectImages struct {
+	Init    string
+	Sidecar string
+}
+
+// GetRevisionInjectImages returns the images injected by the initializer of
+// an Istio revision, read from the configmaps in the Istio namespace of e.
+func (e Environment) GetRevisionInjectImages(cmLister v1.ConfigMapLister, revision string) (InjectImages, error) {
+	spec, err := e.GetRevisionSidecarSpec(cmLister, revision)
+	if err != nil {
+		return InjectImages{}, err
+	}
+	if len(spec.InitContainers) == 0 || len(spec.Containers) == 0 {
+		errStr := "Failed to get inject images"
+		glog.Error(errStr)
+		return InjectImages{}, errors.New(errStr)
+	}
+	return InjectImages{
+		Init:    spec.InitContainers[0].Image,
+		Sidecar: spec.Containers[0].Image,
+	}, nil
+}
+
 // IstioInitializerDisabledNote generates an INFO note if the error string
 // contains "istio-inject configmap not found".
 func IstioInitializerDisabledNote(e, vetterID, vetterType string) *apiv1.Note {
@@ -221,20 +247,63 @@ func ServicePortPrefixed(n string) bool {
 	return false
 }
 
+// ServicePortProtocols returns the protocols Istio recognizes as Service
+// port name prefixes, like "http".
+func ServicePortProtocols() []string {
+	var protocols []string
+	for i := 0; i < len(istioSupportedServicePrefix); i += 2 {
+		protocols = append(protocols, istioSupportedServicePrefix[i])
+	}
+	return protocols
+}
+
+// ServicePortPrefixedWith checks if the Service port name is one of
+// protocols or prefixed with one of them followed by "-".
+func ServicePortPrefixedWith(n string, protocols []string) bool {
+	for _, p := range protocols {
+		if n == p || strings.HasPrefix(n, p+"-") {
+			return true
+		}
+	}
+	return false
+}
+
 // SidecarInjected checks if sidecar is injected in a Pod.
 // Sidecar is considered injected if initializer annotation and proxy container
-// are both present in the Pod Spec.
+// are both present in the Pod Spec. The proxy container is an init container
+// if it runs as a native sidecar.
 func SidecarInjected(p *corev1.Pod) bool {
 	if _, ok := p.Annotations[IstioInitializerPodAnnotation]; !ok {
 		return false
 	}
-	cList := p.Spec.Containers
-	for _, c := range cList {
-		if c.Name == IstioProxyContainerName {
-			return true
+	c, _ := proxyContainer(p.Spec)
+	return c != nil
+}
+
+// proxyContainer returns the proxy container of a pod spec, or nil. native is
+// true if the proxy runs as a native sidecar, an init container which keeps
+// running along the containers of the pod.
+func proxyContainer(s corev1.PodSpec) (c *corev1.Container, native bool) {
+	for i := range s.Containers {
+		if s.Containers[i].Name == IstioProxyContainerName {
+			return &s.Containers[i], false
 		}
 	}
-	return false
+	for i := range s.InitContainers {
+		if s.InitContainers[i].Name == IstioProxyContainerName {
+			return &s.InitContainers[i], true
+		}
+	}
+	return nil, false
+}
+
+// ProxyImage returns the image of the proxy container if present in the pod
+// spec, as a container or as a native sidecar, or an error otherwise.
+func ProxyImage(s corev1.PodSpec) (string, error) {
+	if c, _ := proxyContainer(s); c != nil {
+		return c.Image, nil
+	}
+	return Image(IstioProxyContainerName, s)
 }
 
 func imageFromContainers(n string, cList []corev1.Container) (string, error) {
@@ -260,25 +329,57 @@ func InitImage(n string, s corev1.PodSpec) (string, error) {
 	return imageFromContainers(n, s.InitContainers)
 }
 
-// ListNamespacesInMesh returns the list of Namespaces in the mesh.
-// Namespaces with label "istio-inject=enabled" are considered in
-// the mesh.
+// NamespaceInMesh returns true if pods in the namespace are injected with a
+// sidecar: it has label "istio-injection=enabled", or an "istio.io/rev"
+// label and no "istio-injection" label.
+func NamespaceInMesh(ns *corev1.Namespace) bool {
+	if v, ok := ns.Labels[IstioInjectionLabel]; ok {
+		return v == "enabled"
+	}
+	return ns.Labels[IstioRevisionLabel] != ""
+}
+
+// PodRevision returns the Istio revision which injected the sidecar of a
+// pod, given by its "istio.io/rev" label or else by the one of its
+// namespace ns, which may be nil.
+func PodRevision(p *corev1.Pod, ns *corev1.Namespace) string {
+	if rev := p.Labels[IstioRevisionLabel]; rev != "" {
+		return rev
+	}
+	if ns != nil && ns.Labels[IstioInjectionLabel] == "" && ns.Labels[IstioRevisionLabel] != "" {
+		return ns.Labels[IstioRevisionLabel]
+	}
+	return IstioDefaultRevision
+}
+
+// ListNamespacesInMesh returns the list of Namespaces in the mesh, as
+// determined by NamespaceInMesh. If nsLister is from informer factories
+// returned by Scope.InformerFactories, only the namespaces in the scope are
+// listed.
 func ListNamespacesInMesh(nsLister v1.NamespaceLister) ([]*corev1.Namespace, error) {
-	ns, err := nsLister.List(labels.Set(istioInjectNamespaceLabel).AsSelector())
+	all, err := nsLister.List(labels.Everything())
 	if err != nil {
 		glog.Error("Failed to retrieve namespaces: ", err)
 		return nil, err
 	}
+	ns := []*corev1.Namespace{}
+	for _, n := range all {
+		if NamespaceInMesh(n) {
+			ns = append(ns, n)
+		}
+	}
 	return ns, nil
 }
 
 // ListPodsInMesh returns the list of Pods in the mesh.
-// Pods in Namespaces returned by ListNamespacesInMesh with sidecar
-// injected as determined by SidecarInjected are considered in the mesh.
+// Pods with sidecar injected as determined by SidecarInjected are considered
+// in the mesh, including the ones injected on their own in Namespaces which
+// aren't returned by ListNamespacesInMesh.
 func ListPodsInMesh(nsLister v1.NamespaceLister, podLister v1.PodLister) ([]*corev1.Pod, error) {
 	pods := []*corev1.Pod{}
-	ns, err := ListNamespacesInMesh(nsLister)
+	ns, err := nsLister.List(labels.Everything())
 	if err != nil {
+		glog.Error("Failed to retrieve namespaces: ", err)
 		return nil, err
 	}
 	for _, n := range ns {
@@ -360,10 +461,52 @@ func ListEndpointsInMesh(nsLister v1.NamespaceLister, epLister v1.EndpointsListe
 	return endpoints, nil
 }
 
-// ComputeID returns MD5 checksum of the Note struct which can be used as
-// ID for the note.
-func ComputeID(n *apiv1.Note) string {
-	return fmt.Sprintf("%x", structhash.Md5(n, 1))
+// Kinds of the resources referred to by notes
+var (
+	PodKind            = corev1.SchemeGroupVersion.WithKind("Pod")
+	ServiceKind        = corev1.SchemeGroupVersion.WithKind("Service")
+	VirtualServiceKind = istioClientNet.SchemeGroupVersion.WithKind("VirtualService")
+)
+
+// ObjectRef returns a reference to a resource of kind gvk for Note.Refs.
+// uid may be empty if it isn't known.
+func ObjectRef(gvk schema.GroupVersionKind, namespace, name string, uid types.UID) *apiv1.ObjectReference {
+	return &apiv1.ObjectReference{
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: namespace,
+		Name:      name,
+		Uid:       string(uid),
+	}
+}
+
+// ComputeID returns the identity of a note generated by the vetter with id
+// vetterID: the MD5 checksum of the vetter id, the note type, the group, kind,
+// namespace and name of the resources the note refers to and keys. Keys
+// distinguish notes of the same type about the same resources, e.g. the
+// missing label. The wording of the note and the resource UIDs are not part
+// of the ID, so it is stable across vetter versions and recreated resources.
+func ComputeID(vetterID string, n *apiv1.Note, keys ...string) string {
+	parts := []string{vetterID, n.GetType()}
+	refs := []string{}
+	for _, r := range n.GetRefs() {
+		refs = append(refs, strings.Join([]string{r.GetGroup(), r.GetKind(), r.GetNamespace(), r.GetName()}, "/"))
+	}
+	sort.Strings(refs)
+	parts = append(parts, refs...)
+	parts = append(parts, keys...)
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(parts, "\x00"))))
+}
+
+// ComputeFingerprint returns the MD5 checksum of the content of a note,
+// excluding its id and fingerprint, to detect changes to a note whose ID
+// stays the same.
+func ComputeFingerprint(n *apiv1.Note) string {
+	c := proto.Clone(n).(*apiv1.Note)
+	c.Id = ""
+	c.Fingerprint = ""
+	return fmt.Sprintf("%x", structhash.Md5(c, 1))
 }
 
 // ListVirtualServices returns a list of VirtualService resources in the mesh.
@@ -385,8 +528,9 @@ func ListVirtualServicesInMesh(nsLister v1.NamespaceLister,
 	return virtualServices, nil
 }
 
-// ConvertHostnameToFQDN returns the FQDN if a short name is passed
-func ConvertHostnameToFQDN(hostname string, namespace string) (string, error) {
+// ConvertHostnameToFQDN returns the FQDN in the cluster domain of e if a
+// short name is passed.
+func (e Environment) ConvertHostnameToFQDN(hostname string, namespace string) (string, error) {
 	if (hostname == "") || (namespace == "") {
 		err := errors.New("hostname and namespace cannot be empty")
 		return "", err
@@ -398,7 +542,7 @@ func ConvertHostnameToFQDN(hostname string, namespace string) (string, error) {
 		return hostname, nil
 	}
 	// need to return Fully Qualified Domain Name
-	return hostname + "." + namespace + KubernetesDomainSuffix, nil
+	return hostname + "." + namespace + e.DomainSuffix(), nil
 }
 
 // ProxyStatusPort extracts status port from the cmd arguments for a given container,
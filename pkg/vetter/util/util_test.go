@@ -18,12 +18,20 @@ package util
 
 import (
 	"io/ioutil"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
 )
 
 var _ = Describe("Converting short hostnames to FQDN", func() {
@@ -31,17 +39,17 @@ var _ = Describe("Converting short hostnames to FQDN", func() {
 		namespace := "foo"
 
 		It("Returns an error when hostname and/or namespace are passed as empty strings", func() {
-			_, err := ConvertHostnameToFQDN("", namespace)
+			_, err := Environment{}.ConvertHostnameToFQDN("", namespace)
 			Expect(err).To(HaveOccurred())
-			_, err = ConvertHostnameToFQDN("", "")
+			_, err = Environment{}.ConvertHostnameToFQDN("", "")
 			Expect(err).To(HaveOccurred())
-			_, err = ConvertHostnameToFQDN("host", "")
+			_, err = Environment{}.ConvertHostnameToFQDN("host", "")
 			Expect(err).To(HaveOccurred())
 		})
 
 		It("Returns a FQDN when given a short host name", func() {
 			givenHostname := "reviews"
-			returnedHostname, err := ConvertHostnameToFQDN(givenHostname, namespace)
+			returnedHostname, err := Environment{}.ConvertHostnameToFQDN(givenHostname, namespace)
 			Expect(err).NotTo(HaveOccurred())
 			expectedFqdnHostname := givenHostname + "." + namespace + ".svc.cluster.local"
 			Expect(returnedHostname).NotTo(Equal(givenHostname))
@@ -50,35 +58,35 @@ var _ = Describe("Converting short hostnames to FQDN", func() {
 
 		It("Does not return new host name when given a FQDN", func() {
 			givenHostname := "reviews.foo.svc.cluster.local"
-			returnedHostname, err := ConvertHostnameToFQDN(givenHostname, namespace)
+			returnedHostname, err := Environment{}.ConvertHostnameToFQDN(givenHostname, namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(returnedHostname).To(Equal(givenHostname))
 		})
 
 		It("Does not return a new host name when given an IP address", func() {
 			givenHostname := "255.255.255.0"
-			returnedHostname, err := ConvertHostnameToFQDN(givenHostname, namespace)
+			returnedHostname, err := Environment{}.ConvertHostnameToFQDN(givenHostname, namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(returnedHostname).To(Equal(givenHostname))
 		})
 
 		It("Does not return a new host name when given an *", func() {
 			givenHostname := "*"
-			returnedHostname, err := ConvertHostnameToFQDN(givenHostname, namespace)
+			returnedHostname, err := Environment{}.ConvertHostnameToFQDN(givenHostname, namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(returnedHostname).To(Equal(givenHostname))
 		})
 
 		It("Does not return a new host name when given anything beginning with *", func() {
 			givenHostname := "*.foo.com"
-			returnedHostname, err := ConvertHostnameToFQDN(givenHostname, namespace)
+			returnedHostname, err := Environment{}.ConvertHostnameToFQDN(givenHostname, namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(returnedHostname).To(Equal(givenHostname))
 		})
 
 		It("Does not return a new host name when given a web address", func() {
 			givenHostname := "foo.com"
-			returnedHostname, err := ConvertHostnameToFQDN(givenHostname, namespace)
+			returnedHostname, err := Environment{}.ConvertHostnameToFQDN(givenHostname, namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(returnedHostname).To(Equal(givenHostname))
 		})
@@ -204,3 +212,157 @@ var _ = Describe("Test ProxyStatusPort", func() {
 		Expect(err != nil)
 	})
 })
+
+var _ = Describe("Workload", func() {
+	controller := true
+	owned := func(kind, name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-abcde",
+			Namespace: "default",
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: kind, Name: name, UID: "uid", Controller: &controller}},
+		}}
+	}
+
+	It("returns the deployment owning the replicaset of a pod", func() {
+		p := owned("ReplicaSet", "web-5d9f8", map[string]string{"pod-template-hash": "5d9f8"})
+		Expect(Workload(p)).To(Equal(ObjectRef(DeploymentKind, "default", "web", "")))
+	})
+
+	It("returns statefulsets and daemonsets", func() {
+		Expect(Workload(owned("StatefulSet", "db", nil))).To(Equal(ObjectRef(StatefulSetKind, "default", "db", "uid")))
+		Expect(Workload(owned("DaemonSet", "agent", nil))).To(Equal(ObjectRef(DaemonSetKind, "default", "agent", "uid")))
+	})
+
+	It("returns nil for other pods", func() {
+		Expect(Workload(owned("ReplicaSet", "web", nil))).To(BeNil())
+		Expect(Workload(owned("Job", "batch", nil))).To(BeNil())
+		Expect(Workload(&corev1.Pod{})).To(BeNil())
+	})
+
+	It("resolves the owner of pods through their replicaset", func() {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-legacy",
+			Namespace:       "default",
+			UID:             "rs-uid",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "web-uid", Controller: &controller}},
+		}}
+		bare := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default", UID: "bare-uid"}}
+		Expect(indexer.Add(rs)).To(Succeed())
+		Expect(indexer.Add(bare)).To(Succeed())
+		rsLister := appslisters.NewReplicaSetLister(indexer)
+
+		Expect(Owner(owned("ReplicaSet", "web-legacy", nil), rsLister)).To(Equal(ObjectRef(DeploymentKind, "default", "web", "web-uid")))
+		Expect(Owner(owned("ReplicaSet", "bare", nil), rsLister)).To(Equal(ObjectRef(ReplicaSetKind, "default", "bare", "bare-uid")))
+		p := owned("ReplicaSet", "web-5d9f8", map[string]string{"pod-template-hash": "5d9f8"})
+		Expect(Owner(p, rsLister)).To(Equal(ObjectRef(DeploymentKind, "default", "web", "")))
+		Expect(Owner(p, nil)).To(Equal(ObjectRef(DeploymentKind, "default", "web", "")))
+		Expect(Owner(owned("Job", "batch", nil), rsLister)).To(Equal(ObjectRef(JobKind, "default", "batch", "uid")))
+		Expect(Owner(owned("StatefulSet", "db", nil), rsLister)).To(Equal(ObjectRef(StatefulSetKind, "default", "db", "uid")))
+		Expect(Owner(&corev1.Pod{}, rsLister)).To(BeNil())
+	})
+})
+
+var _ = Describe("Note identity", func() {
+	note := func() *apiv1.Note {
+		return &apiv1.Note{
+			Type:    "missing-app-label",
+			Summary: "Missing app label - ${pod_name}",
+			Level:   apiv1.NoteLevel_WARNING,
+			Attr:    map[string]string{"pod_name": "web-1"},
+			Refs: []*apiv1.ObjectReference{
+				ObjectRef(PodKind, "default", "web-1", "uid-1"),
+				ObjectRef(ServiceKind, "default", "web", "uid-2"),
+			},
+		}
+	}
+
+	It("doesn't depend on the wording, uids or order of the refs", func() {
+		id := ComputeID("AppLabel", note())
+		n := note()
+		n.Summary = "Pod ${pod_name} has no app label"
+		n.Level = apiv1.NoteLevel_ERROR
+		n.Refs[0].Uid = "uid-3"
+		n.Refs[0], n.Refs[1] = n.Refs[1], n.Refs[0]
+		Expect(ComputeID("AppLabel", n)).To(Equal(id))
+	})
+
+	It("depends on the vetter, type, refs and keys", func() {
+		id := ComputeID("AppLabel", note())
+		Expect(ComputeID("Other", note())).NotTo(Equal(id))
+		n := note()
+		n.Type = "other"
+		Expect(ComputeID("AppLabel", n)).NotTo(Equal(id))
+		n = note()
+		n.Refs[0].Name = "web-2"
+		Expect(ComputeID("AppLabel", n)).NotTo(Equal(id))
+		Expect(ComputeID("AppLabel", note(), "version")).NotTo(Equal(id))
+		Expect(ComputeID("AppLabel", note(), "version")).NotTo(Equal(ComputeID("AppLabel", note(), "app")))
+	})
+
+	It("computes a fingerprint of the content", func() {
+		n := note()
+		fp := ComputeFingerprint(n)
+		n.Id = ComputeID("AppLabel", n)
+		n.Fingerprint = fp
+		Expect(ComputeFingerprint(n)).To(Equal(fp))
+		n.Attr["pod_name"] = "web-2"
+		Expect(ComputeFingerprint(n)).NotTo(Equal(fp))
+	})
+})
+
+var _ = Describe("Istio revisions", func() {
+	namespace := func(l map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: l}}
+	}
+
+	It("considers namespaces with injection or revision labels in the mesh", func() {
+		Expect(NamespaceInMesh(namespace(map[string]string{"istio-injection": "enabled"}))).To(BeTrue())
+		Expect(NamespaceInMesh(namespace(map[string]string{"istio.io/rev": "1-20"}))).To(BeTrue())
+		Expect(NamespaceInMesh(namespace(map[string]string{"istio-injection": "disabled", "istio.io/rev": "1-20"}))).To(BeFalse())
+		Expect(NamespaceInMesh(namespace(nil))).To(BeFalse())
+	})
+
+	It("returns the revision of pods", func() {
+		p := &corev1.Pod{}
+		Expect(PodRevision(p, nil)).To(Equal("default"))
+		Expect(PodRevision(p, namespace(map[string]string{"istio.io/rev": "1-20"}))).To(Equal("1-20"))
+		Expect(PodRevision(p, namespace(map[string]string{"istio-injection": "enabled", "istio.io/rev": "1-20"}))).To(Equal("default"))
+		p.Labels = map[string]string{"istio.io/rev": "1-21"}
+		Expect(PodRevision(p, namespace(map[string]string{"istio.io/rev": "1-20"}))).To(Equal("1-21"))
+	})
+
+	It("reads the sidecar spec of a revision", func() {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		injector := configMapFromFile("./testdata/1.1/istio-sidecar-injector.yaml")
+		mesh := configMapFromFile("./testdata/1.1/mesh-config.yaml")
+		Expect(indexer.Add(injector)).To(Succeed())
+		Expect(indexer.Add(mesh)).To(Succeed())
+		canaryInjector := injector.DeepCopy()
+		canaryInjector.Name = "istio-sidecar-injector-canary"
+		canaryInjector.Data["config"] = strings.ReplaceAll(canaryInjector.Data["config"], "1.1.2", "1.2.0")
+		canaryMesh := mesh.DeepCopy()
+		canaryMesh.Name = "istio-canary"
+		Expect(indexer.Add(canaryInjector)).To(Succeed())
+		Expect(indexer.Add(canaryMesh)).To(Succeed())
+		cmLister := v1.NewConfigMapLister(indexer)
+
+		spec, err := Environment{}.GetRevisionSidecarSpec(cmLister, "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Containers[0].Image).To(Equal("docker.io/istio/proxyv2:1.1.2"))
+		spec, err = Environment{}.GetRevisionSidecarSpec(cmLister, "canary")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Containers[0].Image).To(Equal("docker.io/istio/proxyv2:1.2.0"))
+		_, err = Environment{}.GetRevisionSidecarSpec(cmLister, "missing")
+		Expect(err).To(HaveOccurred())
+
+		images, err := Environment{}.GetRevisionInjectImages(cmLister, "canary")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(Equal(InjectImages{
+			Init:    "docker.io/istio/proxy_init:1.2.0",
+			Sidecar: "docker.io/istio/proxyv2:1.2.0",
+		}))
+	})
+})
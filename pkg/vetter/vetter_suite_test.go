@@ -0,0 +1,13 @@
+package vetter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVetter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Vetter Suite")
+}
@@ -0,0 +1,394 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workloadversion vets the sidecar images of the pods in the mesh
+// like meshversion, but generates a single note per workload with the number
+// of mismatched replicas, and a summary of the proxy versions in the mesh.
+package workloadversion
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+
+	corev1 "k8s.io/api/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	vetterID                = "WorkloadVersion"
+	sidecarMismatchNoteType = "workload-sidecar-image-mismatch"
+	sidecarMismatchSummary  = "Mismatched sidecar image - ${workload}"
+	sidecarMismatchMsg      = "${mismatched_pods} of ${pods} pods of ${workload} in namespace ${namespace}" +
+		" are running with sidecar proxy image ${sidecar_images}" +
+		" but your environment is injecting ${inject_sidecar_image} for" +
+		" new workloads. Consider rolling out the pods of ${workload}."
+	initMismatchNoteType = "workload-init-image-mismatch"
+	initMismatchSummary  = "Mismatched istio-init image - ${workload}"
+	initMismatchMsg      = "${mismatched_pods} of ${pods} pods of ${workload} in namespace ${namespace}" +
+		" are running with istio-init image ${init_images}" +
+		" but your environment is injecting ${inject_init_image} for" +
+		" new workloads. Consider rolling out the pods of ${workload}."
+	distributionNoteType = "proxy-version-distribution"
+	distributionSummary  = "Sidecar proxy versions"
+	distributionMsg      = "The ${num_pods} pods in the mesh are running sidecar proxy versions ${versions}."
+	// restartAnnotation is set on the pod template of workloads to roll out
+	// pods with the injected images, as meshversion does.
+	restartAnnotation = "vet.aspenmesh.io/injected-sidecar-image"
+)
+
+//go:embed README-workload-sidecar-image-mismatch.md
+var sidecarImageMismatchDoc string
+
+//go:embed README-workload-init-image-mismatch.md
+var initImageMismatchDoc string
+
+//go:embed README-proxy-version-distribution.md
+var distributionDoc string
+
+// NoteTypes lists the types of notes generated by the vetter.
+var NoteTypes = []*vetter.NoteType{
+	{
+		Type:        sidecarMismatchNoteType,
+		Code:        "IV0901",
+		Vetter:      vetterID,
+		Remediation: "Roll out the pods of the workload so they are injected with the current sidecar image.",
+		DocsURL:     vetter.DocsURL("workloadversion", sidecarMismatchNoteType),
+		Doc:         sidecarImageMismatchDoc,
+	},
+	{
+		Type:        initMismatchNoteType,
+		Code:        "IV0902",
+		Vetter:      vetterID,
+		Remediation: "Roll out the pods of the workload so they are injected with the current istio-init image.",
+		DocsURL:     vetter.DocsURL("workloadversion", initMismatchNoteType),
+		Doc:         initImageMismatchDoc,
+	},
+	{
+		Type:        distributionNoteType,
+		Code:        "IV0903",
+		Vetter:      vetterID,
+		Remediation: "Informational, roll out workloads running old proxy versions to upgrade them.",
+		DocsURL:     vetter.DocsURL("workloadversion", distributionNoteType),
+		Doc:         distributionDoc,
+	},
+}
+
+// WorkloadVersion implements Vetter interface
+type WorkloadVersion struct {
+	podLister v1.PodLister
+	cmLister  v1.ConfigMapLister
+	nsLister  v1.NamespaceLister
+	rsLister  appslisters.ReplicaSetLister
+	env       util.Environment
+}
+
+// workload is a set of pods in the mesh with the same owner, injected by
+// the same Istio revision.
+type workload struct {
+	// ref refers to the owner of the pods, or to the pod itself for pods
+	// without owner.
+	ref      *apiv1.ObjectReference
+	revision string
+	pods     []*corev1.Pod
+}
+
+// name returns the name of w for notes, e.g. "Deployment web".
+func (w *workload) name() string {
+	return w.ref.GetKind() + " " + w.ref.GetName()
+}
+
+// groupWorkloads groups pods by owner, resolved with rsLister, and by the
+// revision given by revision. The workloads are sorted by namespace, kind,
+// name and revision.
+func groupWorkloads(pods []*corev1.Pod, rsLister appslisters.ReplicaSetLister,
+	revision func(*corev1.Pod) string) []*workload {
+	byKey := map[string]*workload{}
+	workloads := []*workload{}
+	for _, p := range pods {
+		ref := util.Owner(p, rsLister)
+		if ref == nil {
+			ref = util.ObjectRef(util.PodKind, p.Namespace, p.Name, p.UID)
+		}
+		rev := revision(p)
+		key := strings.Join([]string{ref.GetNamespace(), ref.GetKind(), ref.GetName(), rev}, "/")
+		w, ok := byKey[key]
+		if !ok {
+			w = &workload{ref: ref, revision: rev}
+			byKey[key] = w
+			workloads = append(workloads, w)
+		}
+		w.pods = append(w.pods, p)
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		if a.ref.GetNamespace() != b.ref.GetNamespace() {
+			return a.ref.GetNamespace() < b.ref.GetNamespace()
+		}
+		if a.ref.GetKind() != b.ref.GetKind() {
+			return a.ref.GetKind() < b.ref.GetKind()
+		}
+		if a.ref.GetName() != b.ref.GetName() {
+			return a.ref.GetName() < b.ref.GetName()
+		}
+		return a.revision < b.revision
+	})
+	return workloads
+}
+
+// restartFix returns a Fix rolling out the pods of w so they are injected
+// with images, or nil if the pod template of w can't be patched.
+func restartFix(w *workload, images util.InjectImages) *apiv1.Fix {
+	switch w.ref.GetKind() {
+	case util.DeploymentKind.Kind, util.StatefulSetKind.Kind, util.DaemonSetKind.Kind:
+		return util.PodTemplateFix("Roll out the pods of "+w.name(),
+			w.ref, nil, map[string]string{restartAnnotation: images.Sidecar})
+	}
+	return nil
+}
+
+// mismatchNote returns a note of type noteType on the pods of w whose image,
+// returned by image, isn't injected, or nil if all pods run injected. Pods
+// for which image fails are ignored.
+func mismatchNote(w *workload, image func(corev1.PodSpec) (string, error), injected string,
+	noteType, summary, msg, imagesAttr, injectAttr string) *apiv1.Note {
+	mismatched := 0
+	seen := map[string]bool{}
+	images := []string{}
+	for _, p := range w.pods {
+		i, err := image(p.Spec)
+		if err != nil || i == injected {
+			continue
+		}
+		mismatched++
+		if !seen[i] {
+			seen[i] = true
+			images = append(images, i)
+		}
+	}
+	if mismatched == 0 {
+		return nil
+	}
+	sort.Strings(images)
+	return &apiv1.Note{
+		Type:    noteType,
+		Summary: summary,
+		Msg:     msg,
+		Level:   apiv1.NoteLevel_WARNING,
+		Attr: map[string]string{
+			"workload":        w.name(),
+			"namespace":       w.ref.GetNamespace(),
+			"revision":        w.revision,
+			"pods":            strconv.Itoa(len(w.pods)),
+			"mismatched_pods": strconv.Itoa(mismatched),
+			imagesAttr:        strings.Join(images, ", "),
+			injectAttr:        injected},
+		Refs: []*apiv1.ObjectReference{w.ref},
+	}
+}
+
+// Separated for unit tests
+func vetWorkloads(workloads []*workload, injImages util.InjectImages) []*apiv1.Note {
+	notes := []*apiv1.Note{}
+	initImage := func(s corev1.PodSpec) (string, error) {
+		return util.InitImage(util.IstioInitContainerName, s)
+	}
+	for _, w := range workloads {
+		if n := mismatchNote(w, util.ProxyImage, injImages.Sidecar, sidecarMismatchNoteType,
+			sidecarMismatchSummary, sidecarMismatchMsg, "sidecar_images", "inject_sidecar_image"); n != nil {
+			n.Fix = restartFix(w, injImages)
+			notes = append(notes, n)
+		}
+		if n := mismatchNote(w, initImage, injImages.Init, initMismatchNoteType,
+			initMismatchSummary, initMismatchMsg, "init_images", "inject_init_image"); n != nil {
+			n.Fix = restartFix(w, injImages)
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// imageVersion returns the version of a container image: its tag, its
+// digest or "latest" if it has neither.
+func imageVersion(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
+
+// plural returns "1 pod" or "n pods".
+func plural(n int) string {
+	if n == 1 {
+		return "1 pod"
+	}
+	return strconv.Itoa(n) + " pods"
+}
+
+// distributionNote returns a note on the number of pods running each sidecar
+// proxy version, or nil if no pod runs a sidecar proxy.
+func distributionNote(pods []*corev1.Pod) *apiv1.Note {
+	counts := map[string]int{}
+	total := 0
+	for _, p := range pods {
+		image, err := util.ProxyImage(p.Spec)
+		if err != nil {
+			continue
+		}
+		counts[imageVersion(image)]++
+		total++
+	}
+	if total == 0 {
+		return nil
+	}
+	versions := make([]string, 0, len(counts))
+	for v := range counts {
+		versions = append(versions, v)
+	}
+	// Most used versions first
+	sort.Slice(versions, func(i, j int) bool {
+		if counts[versions[i]] != counts[versions[j]] {
+			return counts[versions[i]] > counts[versions[j]]
+		}
+		return versions[i] < versions[j]
+	})
+	for i, v := range versions {
+		versions[i] = fmt.Sprintf("%s (%s)", v, plural(counts[v]))
+	}
+	return &apiv1.Note{
+		Type:    distributionNoteType,
+		Summary: distributionSummary,
+		Msg:     distributionMsg,
+		Level:   apiv1.NoteLevel_INFO,
+		Attr: map[string]string{
+			"num_pods":     strconv.Itoa(total),
+			"num_versions": strconv.Itoa(len(counts)),
+			"versions":     strings.Join(versions, ", ")},
+	}
+}
+
+// vetInjectedImages compares the images of the pods in the mesh with the
+// ones injected by the istio-sidecar-injector ConfigMap of their revision,
+// by workload.
+func (m *WorkloadVersion) vetInjectedImages(pods []*corev1.Pod) []*apiv1.Note {
+	notes := []*apiv1.Note{}
+	namespaces := map[string]*corev1.Namespace{}
+	revision := func(p *corev1.Pod) string {
+		ns, ok := namespaces[p.Namespace]
+		if !ok {
+			ns, _ = m.nsLister.Get(p.Namespace)
+			namespaces[p.Namespace] = ns
+		}
+		return util.PodRevision(p, ns)
+	}
+	workloads := groupWorkloads(pods, m.rsLister, revision)
+
+	byRevision := map[string][]*workload{}
+	revisions := []string{}
+	for _, w := range workloads {
+		if _, ok := byRevision[w.revision]; !ok {
+			revisions = append(revisions, w.revision)
+		}
+		byRevision[w.revision] = append(byRevision[w.revision], w)
+	}
+	if len(revisions) == 0 {
+		// Still report a disabled initializer without pods in the mesh.
+		revisions = append(revisions, util.IstioDefaultRevision)
+	}
+	sort.Strings(revisions)
+
+	for _, rev := range revisions {
+		injImages, err := m.env.GetRevisionInjectImages(m.cmLister, rev)
+		if err != nil {
+			if n := util.IstioInitializerDisabledNote(err.Error(), vetterID,
+				sidecarMismatchNoteType); n != nil {
+				notes = append(notes, n)
+			}
+			continue
+		}
+		notes = append(notes, vetWorkloads(byRevision[rev], injImages)...)
+	}
+	return notes
+}
+
+// Vet returns the list of generated notes
+func (m *WorkloadVersion) Vet() ([]*apiv1.Note, error) {
+	pods, err := util.ListPodsInMesh(m.nsLister, m.podLister)
+	if err != nil {
+		// If err != nil when getting pod data, the lower-level error has already
+		// been logged and handled.
+		return nil, err
+	}
+	notes := m.vetInjectedImages(pods)
+	if n := distributionNote(pods); n != nil {
+		notes = append(notes, n)
+	}
+	for i := range notes {
+		notes[i].Id = util.ComputeID(vetterID, notes[i], notes[i].Attr["revision"])
+		notes[i].Fingerprint = util.ComputeFingerprint(notes[i])
+	}
+	vetter.Annotate(notes, NoteTypes)
+	return notes, nil
+}
+
+// Info returns information about the vetter
+func (m *WorkloadVersion) Info() *apiv1.Info {
+	return &apiv1.Info{Id: vetterID, Version: "0.1.0"}
+}
+
+func init() {
+	vetter.Register(vetter.Registration{
+		ID:             vetterID,
+		Description:    "Generates notes per workload if its pods run sidecar or init images other than the injected ones, and on the proxy versions in the mesh.",
+		DefaultEnabled: false,
+		Resources:      []string{vetter.Namespaces, vetter.Pods, vetter.ConfigMaps, vetter.ReplicaSets},
+		NoteTypes:      NoteTypes,
+		New: func(factory vetter.ResourceListGetter) vetter.Vetter {
+			return NewVetter(factory)
+		},
+	})
+}
+
+// NewVetter returns "WorkloadVersion" which implements Vetter Interface
+func NewVetter(factory vetter.ResourceListGetter) *WorkloadVersion {
+	return &WorkloadVersion{
+		podLister: factory.K8s().Core().V1().Pods().Lister(),
+		cmLister:  factory.K8s().Core().V1().ConfigMaps().Lister(),
+		nsLister:  factory.K8s().Core().V1().Namespaces().Lister(),
+		rsLister:  factory.K8s().Apps().V1().ReplicaSets().Lister(),
+		env:       vetter.Environment(factory),
+	}
+}
+
+func NewVetterFromListers(podLister v1.PodLister, cmLister v1.ConfigMapLister, nsLister v1.NamespaceLister,
+	rsLister appslisters.ReplicaSetLister) *WorkloadVersion {
+	return &WorkloadVersion{
+		podLister: podLister,
+		cmLister:  cmLister,
+		nsLister:  nsLister,
+		rsLister:  rsLister,
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2017 Aspen Mesh Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadversion
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	v1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/aspenmesh/istio-vet/api/v1"
+	"github.com/aspenmesh/istio-vet/pkg/vetter/util"
+)
+
+const (
+	v112    = "docker.io/istio/proxyv2:1.1.2"
+	init112 = "docker.io/istio/proxy_init:1.1.2"
+	v100    = "docker.io/istio/proxyv2:1.0.0"
+	init100 = "docker.io/istio/proxy_init:1.0.0"
+)
+
+var controller = true
+
+// pod returns an injected pod controlled by an ownerKind named owner, or
+// without owner if ownerKind is empty.
+func pod(namespace, name, ownerKind, owner, scImage, initImage string) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{util.IstioInitializerPodAnnotation: "{}"},
+		},
+		Spec: corev1.PodSpec{
+			Containers:     []corev1.Container{{Name: util.IstioProxyContainerName, Image: scImage}},
+			InitContainers: []corev1.Container{{Name: util.IstioInitContainerName, Image: initImage}},
+		},
+	}
+	if ownerKind != "" {
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: owner, Controller: &controller}}
+	}
+	return p
+}
+
+// replicaSet returns a ReplicaSet named name controlled by Deployment
+// deployment.
+func replicaSet(namespace, name, deployment string) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       namespace,
+		Name:            name,
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: deployment, Controller: &controller}},
+	}}
+}
+
+func defaultRevision(*corev1.Pod) string {
+	return util.IstioDefaultRevision
+}
+
+// configMapFromFile reads a ConfigMap from the util test data.
+func configMapFromFile(file string) *corev1.ConfigMap {
+	b, err := ioutil.ReadFile("../util/testdata/1.1/" + file)
+	Expect(err).NotTo(HaveOccurred())
+	var cm corev1.ConfigMap
+	Expect(yaml.Unmarshal(b, &cm)).To(Succeed())
+	return &cm
+}
+
+var _ = Describe("WorkloadVersion", func() {
+	images := util.InjectImages{Init: init112, Sidecar: v112}
+
+	It("groups pods by owner", func() {
+		rss := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(rss.Add(replicaSet("web", "web-5d9f8", "web"))).To(Succeed())
+		Expect(rss.Add(replicaSet("web", "web-7c6b4", "web"))).To(Succeed())
+		pods := []*corev1.Pod{
+			pod("web", "web-5d9f8-a", "ReplicaSet", "web-5d9f8", v100, init112),
+			pod("web", "web-7c6b4-b", "ReplicaSet", "web-7c6b4", v112, init112),
+			pod("web", "db-0", "StatefulSet", "db", v100, init100),
+			pod("web", "bare", "", "", v100, init112),
+		}
+
+		workloads := groupWorkloads(pods, appslisters.NewReplicaSetLister(rss), defaultRevision)
+		Expect(workloads).To(HaveLen(3))
+		Expect(workloads[0].name()).To(Equal("Deployment web"))
+		Expect(workloads[0].pods).To(HaveLen(2))
+		Expect(workloads[1].name()).To(Equal("Pod bare"))
+		Expect(workloads[2].name()).To(Equal("StatefulSet db"))
+	})
+
+	It("reports mismatched images once per workload", func() {
+		pods := []*corev1.Pod{}
+		for _, n := range []string{"a", "b", "c"} {
+			pods = append(pods, pod("web", "web-"+n, "DaemonSet", "web", v100, init112))
+		}
+		pods = append(pods, pod("web", "web-d", "DaemonSet", "web", v112, init112))
+		pods = append(pods, pod("batch", "job-a", "Job", "job", "docker.io/istio/proxyv2:0.8.0", init100))
+		pods = append(pods, pod("batch", "job-b", "Job", "job", v100, init112))
+
+		notes := vetWorkloads(groupWorkloads(pods, nil, defaultRevision), images)
+		Expect(notes).To(HaveLen(3))
+
+		Expect(notes[0].Type).To(Equal(sidecarMismatchNoteType))
+		Expect(notes[0].Attr).To(Equal(map[string]string{
+			"workload":             "Job job",
+			"namespace":            "batch",
+			"revision":             util.IstioDefaultRevision,
+			"pods":                 "2",
+			"mismatched_pods":      "2",
+			"sidecar_images":       "docker.io/istio/proxyv2:0.8.0, " + v100,
+			"inject_sidecar_image": v112,
+		}))
+		Expect(notes[0].Refs).To(Equal([]*apiv1.ObjectReference{util.ObjectRef(util.JobKind, "batch", "job", "")}))
+		// The pod template of Jobs can't be patched
+		Expect(notes[0].Fix).To(BeNil())
+
+		Expect(notes[1].Type).To(Equal(initMismatchNoteType))
+		Expect(notes[1].Attr["mismatched_pods"]).To(Equal("1"))
+		Expect(notes[1].Attr["init_images"]).To(Equal(init100))
+		Expect(notes[1].Attr["inject_init_image"]).To(Equal(init112))
+
+		Expect(notes[2].Type).To(Equal(sidecarMismatchNoteType))
+		Expect(notes[2].Attr["workload"]).To(Equal("DaemonSet web"))
+		Expect(notes[2].Attr["pods"]).To(Equal("4"))
+		Expect(notes[2].Attr["mismatched_pods"]).To(Equal("3"))
+		Expect(notes[2].Fix).NotTo(BeNil())
+		Expect(notes[2].Fix.Target.Kind).To(Equal("DaemonSet"))
+		Expect(notes[2].Fix.Patch).To(Equal(`{"spec":{"template":{"metadata":{"annotations":{"` +
+			restartAnnotation + `":"` + v112 + `"}}}}}`))
+	})
+
+	It("summarizes the proxy versions", func() {
+		Expect(imageVersion(v112)).To(Equal("1.1.2"))
+		Expect(imageVersion("localhost:5000/istio/proxyv2")).To(Equal("latest"))
+		Expect(imageVersion("istio/proxyv2@sha256:abc")).To(Equal("sha256:abc"))
+
+		pods := []*corev1.Pod{
+			pod("web", "a", "", "", v100, init100),
+			pod("web", "b", "", "", v112, init112),
+			pod("web", "c", "", "", v112, init112),
+			pod("web", "d", "", "", "docker.io/istio/proxyv2:1.2.0", init112),
+		}
+		n := distributionNote(pods)
+		Expect(n.Attr).To(Equal(map[string]string{
+			"num_pods":     "4",
+			"num_versions": "3",
+			"versions":     "1.1.2 (2 pods), 1.0.0 (1 pod), 1.2.0 (1 pod)",
+		}))
+		Expect(distributionNote(nil)).To(BeNil())
+	})
+
+	It("compares workloads with the images of their revision", func() {
+		cms := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		injector := configMapFromFile("istio-sidecar-injector.yaml")
+		canaryInjector := injector.DeepCopy()
+		canaryInjector.Name = "istio-sidecar-injector-canary"
+		canaryInjector.Data["config"] = strings.ReplaceAll(canaryInjector.Data["config"], "1.1.2", "1.2.0")
+		for _, cm := range []*corev1.ConfigMap{injector, configMapFromFile("mesh-config.yaml"), canaryInjector} {
+			Expect(cms.Add(cm)).To(Succeed())
+		}
+		nss := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(nss.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Labels: map[string]string{util.IstioInjectionLabel: "enabled"}}})).To(Succeed())
+		rss := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		Expect(rss.Add(replicaSet("web", "web-5d9f8", "web"))).To(Succeed())
+		pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		canary := pod("web", "web-5d9f8-c", "ReplicaSet", "web-5d9f8", "docker.io/istio/proxyv2:1.2.0",
+			"docker.io/istio/proxy_init:1.2.0")
+		canary.Labels = map[string]string{util.IstioRevisionLabel: "canary"}
+		for _, p := range []*corev1.Pod{
+			pod("web", "web-5d9f8-a", "ReplicaSet", "web-5d9f8", v112, init112),
+			pod("web", "web-5d9f8-b", "ReplicaSet", "web-5d9f8", v100, init112),
+			canary,
+		} {
+			Expect(pods.Add(p)).To(Succeed())
+		}
+
+		v := NewVetterFromListers(v1.NewPodLister(pods), v1.NewConfigMapLister(cms), v1.NewNamespaceLister(nss),
+			appslisters.NewReplicaSetLister(rss))
+		notes, err := v.Vet()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(HaveLen(2))
+		Expect(notes[0].Type).To(Equal(sidecarMismatchNoteType))
+		Expect(notes[0].Attr["workload"]).To(Equal("Deployment web"))
+		Expect(notes[0].Attr["revision"]).To(Equal(util.IstioDefaultRevision))
+		Expect(notes[0].Attr["pods"]).To(Equal("2"))
+		Expect(notes[0].Attr["mismatched_pods"]).To(Equal("1"))
+		Expect(notes[0].Code).To(Equal("IV0901"))
+		Expect(notes[0].Id).NotTo(BeEmpty())
+		Expect(notes[1].Type).To(Equal(distributionNoteType))
+		Expect(notes[1].Attr["versions"]).To(Equal("1.0.0 (1 pod), 1.1.2 (1 pod), 1.2.0 (1 pod)"))
+	})
+})
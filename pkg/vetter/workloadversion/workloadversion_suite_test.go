@@ -0,0 +1,13 @@
+package workloadversion
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWorkloadVersion(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WorkloadVersion Suite")
+}